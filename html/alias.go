@@ -0,0 +1,50 @@
+package html
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"slices"
+	"strings"
+)
+
+// aliasTarget follows the alias chain from name to a template name. Names
+// that are not aliases are returned as is.
+func (c *Config) aliasTarget(name string) string {
+	for range len(c.Aliases) {
+		target, ok := c.Aliases[name]
+		if !ok {
+			break
+		}
+		name = target
+	}
+	return name
+}
+
+// resolve returns the template name renders of name should use, logging a
+// deprecation warning in development mode when name is an alias
+func (h *HTMLTemplate) resolve(name string) string {
+	target := h.config.aliasTarget(name)
+	if target != name && h.config.Development {
+		log.Printf("Template %s is a deprecated alias of %s", name, target)
+	}
+	return target
+}
+
+// checkAliases reports aliases that shadow a template in t or form a cycle
+func (c *Config) checkAliases(t *template.Template) error {
+	for old := range c.Aliases {
+		if t.Lookup(old) != nil {
+			return fmt.Errorf("alias %s conflicts with a template of the same name", old)
+		}
+
+		chain := []string{old}
+		for name, ok := c.Aliases[old]; ok; name, ok = c.Aliases[name] {
+			if slices.Contains(chain, name) {
+				return fmt.Errorf("alias cycle: %s", strings.Join(append(chain, name), " -> "))
+			}
+			chain = append(chain, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package html
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAlias(t *testing.T) {
+	logs := captureLogs(t)
+	h := newTestEngine(t, map[string]string{
+		"people.html": `<p>{{.}}</p>`,
+		"page.html":   `{{partial "profile.html" .}}`,
+	}, WithDevelopment(true), WithAlias("profile.html", "account.html"), WithAlias("account.html", "people.html"))
+
+	if !h.HasTemplate("profile.html") || !h.HasTemplate("account.html") {
+		t.Fatal("HasTemplate doesn't resolve aliases")
+	}
+	if got := renderString(t, h, "profile.html", "ann"); got != "<p>ann</p>" {
+		t.Fatalf("got %q", got)
+	}
+	if got := renderString(t, h, "page.html", "bob"); got != "<p>bob</p>" {
+		t.Fatalf("partial got %q", got)
+	}
+	if !strings.Contains(logs.String(), "Template profile.html is a deprecated alias of people.html") {
+		t.Fatalf("no deprecation warning in %q", logs.String())
+	}
+}
+
+func TestAliasErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.html": `a`, "b.html": `b`})
+
+	tests := []struct {
+		opts []Option
+		want string
+	}{
+		{[]Option{WithAlias("a.html", "b.html")}, "alias a.html conflicts"},
+		{[]Option{WithAlias("x.html", "y.html"), WithAlias("y.html", "x.html")}, "alias cycle: "},
+	}
+	for _, tt := range tests {
+		_, err := Sparkle("*.html", append(tt.opts, WithTemplateDir(dir))...).CreateEngine()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("got %v, want %q", err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package html
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// ampDisallowed are elements AMP pages must not contain, most of them
+// replaced by an amp-* component
+var ampDisallowed = []string{
+	"applet", "audio", "base", "embed", "frame", "frameset", "iframe",
+	"img", "object", "param", "video",
+}
+
+// ampProblems returns the violations of a subset of the AMP rules in src:
+// inline style attributes, <style> other than amp-custom and
+// amp-boilerplate, author scripts other than JSON-LD and the AMP runtime,
+// disallowed elements and a missing canonical link. Documents whose <html>
+// tag carries neither "amp" nor "⚡" are not AMP pages and have none. This
+// is a check for common template mistakes, not a full validator.
+func ampProblems(src []byte) []string {
+	var problems []string
+	amp, canonical, custom := false, false, 0
+
+	z := xhtml.NewTokenizer(bytes.NewReader(src))
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			break
+		}
+		if tt != xhtml.StartTagToken && tt != xhtml.SelfClosingTagToken {
+			continue
+		}
+
+		token := z.Token()
+		attr := func(key string) (string, bool) {
+			for _, a := range token.Attr {
+				if a.Key == key {
+					return a.Val, true
+				}
+			}
+			return "", false
+		}
+
+		tag := token.Data
+		if _, ok := attr("style"); ok {
+			problems = append(problems, fmt.Sprintf("inline style attribute on <%s>", tag))
+		}
+
+		switch {
+		case tag == "html":
+			_, bolt := attr("⚡")
+			_, a := attr("amp")
+			amp = bolt || a
+		case tag == "style":
+			if _, ok := attr("amp-custom"); ok {
+				custom++
+			} else if _, ok := attr("amp-boilerplate"); !ok {
+				problems = append(problems, "<style> without amp-custom or amp-boilerplate")
+			}
+		case tag == "script":
+			typ, _ := attr("type")
+			src, _ := attr("src")
+			if typ != "application/ld+json" && !strings.HasPrefix(src, "https://cdn.ampproject.org/") {
+				problems = append(problems, "<script> other than JSON-LD or the AMP runtime")
+			}
+		case tag == "link":
+			if rel, _ := attr("rel"); slices.Contains(strings.Fields(strings.ToLower(rel)), "canonical") {
+				canonical = true
+			}
+		case slices.Contains(ampDisallowed, tag):
+			problems = append(problems, fmt.Sprintf("disallowed <%s>", tag))
+		}
+	}
+
+	if !amp {
+		return nil
+	}
+	if !canonical {
+		problems = append(problems, `missing <link rel="canonical">`)
+	}
+	if custom > 1 {
+		problems = append(problems, fmt.Sprintf("%d <style amp-custom> elements, at most one allowed", custom))
+	}
+	return problems
+}
@@ -0,0 +1,56 @@
+package html
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAMPProblems(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{`<html amp><head><link rel="canonical" href="/"><style amp-custom>p{}</style>` +
+			`<script async src="https://cdn.ampproject.org/v0.js"></script>` +
+			`<script type="application/ld+json">{}</script></head><body><amp-img src="a.png"></amp-img></body></html>`, nil},
+		{`<html ⚡><head><link rel="canonical" href="/"></head><body><p style="color:red"><img src="a.png"></p></body></html>`,
+			[]string{"inline style attribute on <p>", "disallowed <img>"}},
+		{`<html amp><head><style>p{}</style><style amp-custom></style><style amp-custom></style><script>alert(1)</script></head></html>`,
+			[]string{"<style> without amp-custom or amp-boilerplate", "<script> other than JSON-LD or the AMP runtime",
+				`missing <link rel="canonical">`, "2 <style amp-custom> elements, at most one allowed"}},
+		{`<html><body><img src="a.png" style="x"></body></html>`, nil},
+	}
+	for _, tt := range tests {
+		if got := ampProblems([]byte(tt.src)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestAMPCheck(t *testing.T) {
+	files := map[string]string{
+		"amp.html": `<html amp><head></head><body><iframe src="/x"></iframe></body></html>`,
+		"ok.html":  `<html amp><head><link rel="canonical" href="/"></head><body></body></html>`,
+	}
+
+	logs := captureLogs(t)
+	h := newTestEngine(t, files, WithAMPCheck(true), WithDevelopment(true))
+	renderString(t, h, "ok.html", nil)
+	if strings.Contains(logs.String(), "AMP:") {
+		t.Fatalf("compliant page logged %q", logs.String())
+	}
+	renderString(t, h, "amp.html", nil)
+	for _, want := range []string{"Template amp.html AMP: disallowed <iframe>", `Template amp.html AMP: missing <link rel="canonical">`} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("no %q in %q", want, logs.String())
+		}
+	}
+
+	logs.Reset()
+	h = newTestEngine(t, files, WithAMPCheck(true))
+	renderString(t, h, "amp.html", nil)
+	if strings.Contains(logs.String(), "AMP:") {
+		t.Fatalf("production logged %q", logs.String())
+	}
+}
@@ -0,0 +1,86 @@
+package html
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// AssetHandler serves the files in AssetDir under prefix. Requests carrying
+// a version query, as produced by the asset helper, are cached for a year
+// since a new version gets a new URL; unversioned requests must revalidate.
+// Responses carry an ETag and Content-Type, and paths escaping the asset
+// directory are rejected.
+func (h *HTMLTemplate) AssetHandler(prefix string) http.Handler {
+	return http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, err := h.config.openAsset(r.URL.Path)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+		content, ok := f.(io.ReadSeeker)
+		if !ok {
+			http.Error(w, "asset not seekable", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()))
+		if r.URL.Query().Get("v") != "" {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+
+		// ServeContent sets Content-Type from the extension and answers
+		// conditional requests against the ETag
+		http.ServeContent(w, r, info.Name(), info.ModTime(), content)
+	}))
+}
+
+// openAsset opens name in AssetDir, rejecting paths that escape it
+func (c *Config) openAsset(name string) (fs.File, error) {
+	name = strings.TrimPrefix(name, "/")
+	if !fs.ValidPath(name) || name == "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return os.DirFS(c.AssetDir).Open(name)
+}
+
+// assetHash returns a short hash of the asset's content, or "" if it can't
+// be read. Hashes are cached outside development mode, where files change.
+func (c *Config) assetHash(name string) string {
+	if !c.Development {
+		if v, ok := c.assetHashes.Load(name); ok {
+			return v.(string)
+		}
+	}
+
+	f, err := c.openAsset(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(content)
+	v := hex.EncodeToString(sum[:6])
+
+	if !c.Development {
+		c.assetHashes.Store(name, v)
+	}
+	return v
+}
@@ -0,0 +1,135 @@
+package html
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestAssetHashStaysInAssetDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"assets/app.css": "body{}",
+		"secret.txt":     "key",
+	})
+	c := Sparkle("*.html", WithAssetDir(filepath.Join(dir, "assets"))).(*html).config
+
+	if c.assetHash("app.css") == "" || c.assetHash("/app.css") == "" {
+		t.Fatal("want a hash for an asset")
+	}
+	for _, name := range []string{"../secret.txt", "/../secret.txt", "sub/../../secret.txt", "."} {
+		if v := c.assetHash(name); v != "" {
+			t.Errorf("assetHash(%q) = %q, want it rejected", name, v)
+		}
+	}
+}
+
+func TestAssetHandler(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"assets/app.css": "body{}", "secret.txt": "key"})
+	h := newTestEngine(t, map[string]string{"page.html": ``}, WithAssetDir(filepath.Join(dir, "assets")))
+	handler := h.AssetHandler("/static/")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/static/app.css?v=1", nil))
+	if rec.Code != 200 || rec.Body.String() != "body{}" {
+		t.Fatalf("got %d %q", rec.Code, rec.Body.String())
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=31536000, immutable" {
+		t.Fatalf("versioned Cache-Control %q", cc)
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/static/app.css", nil)
+	req.URL.Path = "/static/../secret.txt"
+	handler.ServeHTTP(rec, req)
+	if rec.Code != 404 {
+		t.Fatalf("traversal got %d, want 404", rec.Code)
+	}
+}
+
+func TestAssetHandlerCaching(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"assets/app.css": "body{}", "assets/img/logo.svg": "<svg/>"})
+	h := newTestEngine(t, map[string]string{"page.html": ``}, WithAssetDir(filepath.Join(dir, "assets")))
+	handler := h.AssetHandler("/static/")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/static/app.css", nil))
+	etag := rec.Header().Get("ETag")
+	if rec.Header().Get("Cache-Control") != "no-cache" || etag == "" {
+		t.Fatalf("unversioned headers %v", rec.Header())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/css; charset=utf-8" {
+		t.Fatalf("Content-Type %q", ct)
+	}
+
+	req := httptest.NewRequest("GET", "/static/app.css", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != 304 {
+		t.Fatalf("conditional request got %d, want 304", rec.Code)
+	}
+
+	for _, p := range []string{"/static/img", "/static/", "/static/nope.css"} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", p, nil))
+		if rec.Code != 404 {
+			t.Errorf("%s got %d, want 404", p, rec.Code)
+		}
+	}
+}
+
+func TestAutoAssetVersion(t *testing.T) {
+	dir := t.TempDir()
+	assets := filepath.Join(dir, "assets")
+	writeFiles(t, assets, map[string]string{"app.css": "body{}"})
+	c := Sparkle("*.html", WithAssetDir(assets), WithAutoAssetVersion(true), WithAssetVersion("3")).(*html).config
+
+	first := c.assetPath("app.css")
+	if first != filepath.Join(assets, "app.css")+"?v="+c.assetHash("app.css") {
+		t.Fatalf("got %q, want the content hash", first)
+	}
+	if got := c.assetPath("missing.css"); got != filepath.Join(assets, "missing.css")+"?v=3" {
+		t.Fatalf("unreadable asset got %q, want the fixed version", got)
+	}
+
+	// Hashes are cached outside development mode
+	writeFiles(t, assets, map[string]string{"app.css": "body{color:red}"})
+	if got := c.assetPath("app.css"); got != first {
+		t.Fatalf("got %q, want the cached %q", got, first)
+	}
+	c.Development = true
+	if got := c.assetPath("app.css"); got == first {
+		t.Fatal("development mode kept the stale hash")
+	}
+}
+
+func TestAssetURLRewriter(t *testing.T) {
+	h := newTestEngine(t, map[string]string{"page.html": `<link href="{{asset "app.css"}}">`},
+		WithAssetDir("static"), WithAssetVersion("3"),
+		WithAssetURLRewriter(func(url string) string { return "https://cdn.example.com/" + url }))
+
+	if got := renderString(t, h, "page.html", nil); got != `<link href="https://cdn.example.com/static/app.css?v=3">` {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestAssetHost(t *testing.T) {
+	tests := []struct {
+		opts []Option
+		want string
+	}{
+		{nil, "assets/app.css?v=3"},
+		{[]Option{WithAssetHost("https://cdn.example.com")}, "https://cdn.example.com/assets/app.css?v=3"},
+		{[]Option{WithAssetHost("https://cdn.example.com/")}, "https://cdn.example.com/assets/app.css?v=3"},
+	}
+	for _, tt := range tests {
+		opts := append([]Option{WithAssetDir("assets"), WithAssetVersion("3")}, tt.opts...)
+		c := Sparkle("*.html", opts...).(*html).config
+		if got := c.assetPath("app.css"); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package html
+
+import (
+	"bytes"
+	"io"
+	"mime"
+)
+
+// utf8BOM is the UTF-8 byte order mark
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// bomWriter prepends a BOM to the first write unless the output already
+// starts with one
+type bomWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (b *bomWriter) Write(p []byte) (int, error) {
+	if !b.started && len(p) > 0 {
+		b.started = true
+		if !bytes.HasPrefix(p, utf8BOM) {
+			if _, err := b.w.Write(utf8BOM); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return b.w.Write(p)
+}
+
+// withBOM wraps w to prepend a BOM when OutputBOM is set
+func (c *Config) withBOM(w io.Writer) io.Writer {
+	if !c.OutputBOM {
+		return w
+	}
+	return &bomWriter{w: w}
+}
+
+// withCharset replaces the charset parameter of a content type with the
+// configured Charset
+func (c *Config) withCharset(contentType string) string {
+	if c.Charset == "" {
+		return contentType
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	params["charset"] = c.Charset
+	return mime.FormatMediaType(mediaType, params)
+}
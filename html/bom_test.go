@@ -0,0 +1,50 @@
+package html
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOutputBOM(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":   `a,{{.}}`,
+		"marked.html": "\ufeffa,{{.}}",
+	}, WithOutputBOM(true))
+
+	for _, name := range []string{"page.html", "marked.html"} {
+		got := renderString(t, h, name, "é")
+		if got != "\ufeffa,é" {
+			t.Errorf("%s: got %q", name, got)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	if err := h.RenderHTTP(w, httptest.NewRequest("GET", "/", nil), "page.html", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(w.Body.Bytes(), []byte("\ufeffa,b")) {
+		t.Fatalf("RenderHTTP got %q", w.Body.String())
+	}
+}
+
+func TestCharset(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `<p>hi</p>`,
+		"feed.html": `<feed/>`,
+	}, WithCharset("ISO-8859-1"), WithContentType("feed.html", "application/atom+xml"))
+
+	tests := map[string]string{
+		"page.html": "text/html; charset=ISO-8859-1",
+		"feed.html": "application/atom+xml",
+	}
+	for name, want := range tests {
+		w := httptest.NewRecorder()
+		if err := h.RenderHTTP(w, httptest.NewRequest("GET", "/", nil), name, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := w.Header().Get("Content-Type"); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+)
+
+// OutputMode is how Render delivers output to its writer
+type OutputMode string
+
+const (
+	// OutputDirect executes templates straight into the writer
+	OutputDirect OutputMode = "direct"
+	// OutputBuffered renders the whole page to memory first, and writes
+	// it only once the render is done
+	OutputBuffered OutputMode = "buffered"
+)
+
+// OutputTransform post-processes the output of the templates whose names
+// match Pattern, a path.Match glob such as "pages/*"
+type OutputTransform struct {
+	Pattern string
+	Fn      func([]byte) ([]byte, error)
+}
+
+// OutputMode reports whether renders write directly or through a buffer.
+// Output is buffered only when an enabled feature needs the full page
+// before writing it: a panic template, WithBufferedOutput, or output
+// transforms, which buffer the templates they match.
+func (h *HTMLTemplate) OutputMode() OutputMode {
+	return h.config.outputMode()
+}
+
+func (c *Config) outputMode() OutputMode {
+	if c.PanicTemplate != "" || c.Buffered || len(c.OutputTransforms) > 0 {
+		return OutputBuffered
+	}
+	return OutputDirect
+}
+
+// render executes name into w in the configured output mode
+func (h *HTMLTemplate) render(ctx context.Context, w io.Writer, name string, data any) error {
+	return h.renderOutput(ctx, w, name, func(w io.Writer) error {
+		return h.execute(ctx, w, name, data)
+	})
+}
+
+// renderOutput runs exec, which renders name, into w in the configured
+// output mode. Layout renders go through it too, so both paths buffer,
+// transform and recover alike.
+func (h *HTMLTemplate) renderOutput(ctx context.Context, w io.Writer, name string, exec func(io.Writer) error) error {
+	return h.transformOutput(name, w, func(w io.Writer) error {
+		switch {
+		case h.config.PanicTemplate != "":
+			return h.renderRecovering(ctx, w, name, exec)
+		case h.config.Buffered:
+			var buf bytes.Buffer
+			if err := exec(&buf); err != nil {
+				return err
+			}
+			_, err := buf.WriteTo(w)
+			return err
+		default:
+			return exec(w)
+		}
+	})
+}
+
+// transformOutput runs render and writes its output to w, passed through
+// the output transforms matching name, or its file below TemplateDir, in
+// registration order. Without any render writes to w directly.
+func (h *HTMLTemplate) transformOutput(name string, w io.Writer, render func(io.Writer) error) error {
+	var fns []func([]byte) ([]byte, error)
+	if len(h.config.OutputTransforms) > 0 {
+		file := h.templateFile(name)
+		for _, t := range h.config.OutputTransforms {
+			if matchTemplate(t.Pattern, name, file) {
+				fns = append(fns, t.Fn)
+			}
+		}
+	}
+	if len(fns) == 0 {
+		return render(w)
+	}
+
+	var buf bytes.Buffer
+	if err := render(&buf); err != nil {
+		return err
+	}
+	out := buf.Bytes()
+	for _, fn := range fns {
+		var err error
+		if out, err = fn(out); err != nil {
+			return fmt.Errorf("transform output of %s: %w", name, err)
+		}
+	}
+	_, err := w.Write(out)
+	return err
+}
@@ -0,0 +1,113 @@
+package html
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOutputMode(t *testing.T) {
+	files := map[string]string{"page.html": `{{.}}`}
+	if got := newTestEngine(t, files).OutputMode(); got != OutputDirect {
+		t.Fatalf("default mode %q, want direct", got)
+	}
+	if got := newTestEngine(t, files, WithBufferedOutput(true)).OutputMode(); got != OutputBuffered {
+		t.Fatalf("mode %q, want buffered", got)
+	}
+}
+
+func TestBufferedLayoutFailure(t *testing.T) {
+	for _, cache := range []bool{false, true} {
+		h := newTestEngine(t, map[string]string{
+			"page.html":         `{{fail}}`,
+			"layouts/base.html": `<main>before{{template "content" .}}</main>`,
+		}, WithFuncs(map[string]any{"fail": func() (string, error) { return "", errors.New("failed") }}),
+			WithLayoutDir("layouts"), WithCache(cache), WithBufferedOutput(true))
+
+		var buf bytes.Buffer
+		if err := h.RenderWithLayout(&buf, &RenderData{Layout: "base", View: "page.html"}); err == nil {
+			t.Fatalf("cache %v: want an error", cache)
+		}
+		if buf.Len() != 0 {
+			t.Fatalf("cache %v: failed buffered render wrote %q", cache, buf.String())
+		}
+	}
+}
+
+func TestRenderHeadersReportMode(t *testing.T) {
+	captureLogs(t)
+	h := newTestEngine(t, map[string]string{"page.html": `{{.}}`},
+		WithDevelopment(true), WithRenderHeaders(true), WithBufferedOutput(true))
+
+	rec := httptest.NewRecorder()
+	if err := h.RenderHTTP(rec, httptest.NewRequest("GET", "/", nil), "page.html", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get("X-Render-Mode"); got != string(OutputBuffered) {
+		t.Fatalf("X-Render-Mode %q, want buffered", got)
+	}
+	if rec.Header().Get("X-Render-Size") != "1" {
+		t.Fatalf("X-Render-Size %q", rec.Header().Get("X-Render-Size"))
+	}
+}
+
+func BenchmarkRenderOutputMode(b *testing.B) {
+	files := map[string]string{
+		"page.html": `<ul>{{range .}}<li>{{.}}</li>{{end}}</ul>`,
+	}
+	items := make([]int, 200)
+	for i := range items {
+		items[i] = i
+	}
+	for _, mode := range []OutputMode{OutputDirect, OutputBuffered} {
+		b.Run(string(mode), func(b *testing.B) {
+			h := newTestEngine(b, files, WithBufferedOutput(mode == OutputBuffered))
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := h.Render(io.Discard, "page.html", items); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestTransformFor(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"pages/home.html":    "<p>\n  home\n</p>",
+		"emails/signup.html": "<p>\n  signup\n</p>",
+		"emails/broken.html": "<p>",
+	})
+	minify := func(b []byte) ([]byte, error) { return bytes.ReplaceAll(b, []byte("\n  "), nil), nil }
+	tag := func(label string) func([]byte) ([]byte, error) {
+		return func(b []byte) ([]byte, error) { return append(b, label...), nil }
+	}
+
+	engine, err := Sparkle("*/*.html", WithTemplateDir(dir),
+		WithTransformFor("pages/*", minify),
+		WithTransformFor("*", tag("[all]")),
+		WithTransformFor("pages/*", tag("[pages]")),
+		WithTransformFor("emails/broken.html", func([]byte) ([]byte, error) { return nil, errors.New("bad markup") }),
+	).CreateEngine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := engine.(*HTMLTemplate)
+
+	if got := renderString(t, h, "home.html", nil); got != "<p>home\n</p>[all][pages]" {
+		t.Fatalf("page got %q", got)
+	}
+	if got := renderString(t, h, "signup.html", nil); got != "<p>\n  signup\n</p>[all]" {
+		t.Fatalf("email got %q", got)
+	}
+
+	var buf bytes.Buffer
+	err = h.Render(&buf, "broken.html", nil)
+	if err == nil || !strings.Contains(err.Error(), "transform output of broken.html: bad markup") || buf.Len() != 0 {
+		t.Fatalf("got %q, %v", buf.String(), err)
+	}
+}
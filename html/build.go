@@ -0,0 +1,45 @@
+package html
+
+import (
+	"io"
+	"mime"
+	"strings"
+	"time"
+)
+
+// BuildInfo identifies the build serving the pages, for the comment
+// enabled by WithBuildComment
+type BuildInfo struct {
+	Commit string    // source revision, such as a git SHA
+	Time   time.Time // when the binary was built
+}
+
+// writeBuildComment appends the build comment to the output of name when
+// it is enabled and name renders HTML
+func (c *Config) writeBuildComment(w io.Writer, name string) error {
+	if !c.BuildComment {
+		return nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(c.contentType(name)); mediaType != "text/html" {
+		return nil
+	}
+
+	var fields []string
+	if c.AssetVersion != "" {
+		fields = append(fields, "assets="+c.AssetVersion)
+	}
+	if !c.Build.Time.IsZero() {
+		fields = append(fields, "built="+c.Build.Time.UTC().Format(time.RFC3339))
+	}
+	if c.Build.Commit != "" {
+		fields = append(fields, "commit="+c.Build.Commit)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	// "--" would end the comment early
+	text := strings.ReplaceAll(strings.Join(fields, " "), "--", "")
+	_, err := io.WriteString(w, "\n<!-- build "+text+" -->\n")
+	return err
+}
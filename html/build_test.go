@@ -0,0 +1,28 @@
+package html
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildComment(t *testing.T) {
+	files := map[string]string{
+		"page.html":  `<p>page</p>`,
+		"plain.html": `plain`,
+	}
+	info := BuildInfo{Commit: "abc--123", Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))}
+	h := newTestEngine(t, files, WithBuildInfo(info), WithBuildComment(true), WithAssetVersion("7"),
+		WithContentType("plain.html", "text/plain; charset=utf-8"))
+
+	if got, want := renderString(t, h, "page.html", nil), "<p>page</p>\n<!-- build assets=7 built=2024-05-01T10:00:00Z commit=abc123 -->\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if got := renderString(t, h, "plain.html", nil); got != "plain" {
+		t.Fatalf("non-HTML got %q", got)
+	}
+
+	h = newTestEngine(t, files, WithBuildInfo(info))
+	if got := renderString(t, h, "page.html", nil); got != "<p>page</p>" {
+		t.Fatalf("disabled got %q", got)
+	}
+}
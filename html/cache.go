@@ -0,0 +1,81 @@
+package html
+
+import (
+	"io"
+	"strconv"
+	"sync"
+)
+
+// layoutEntry pools render sets wired for one layout and view pair, so the
+// clone, content block and escaping are not redone on every render. Entries
+// are keyed on the generation of the template set they were wired from, so
+// a set wired while a reload swaps the templates never joins the new pool.
+type layoutEntry struct {
+	layout string
+	view   string
+	sets   sync.Pool
+}
+
+// acquireLayoutSet returns a render set whose content block renders view
+func (h *HTMLTemplate) acquireLayoutSet(w io.Writer, layout, view string) (*renderSet, error) {
+	h.mu.RLock()
+	gen := h.cfgGen
+	h.mu.RUnlock()
+
+	e := h.layoutEntry(layout, view, gen)
+	if s, ok := e.sets.Get().(*renderSet); ok {
+		s.rs.reset(w, layout)
+		return s, nil
+	}
+
+	t, wired, err := h.wireLayout(layout, view)
+	if err != nil {
+		return nil, err
+	}
+	if wired != gen {
+		e = h.layoutEntry(layout, view, wired)
+	}
+
+	rs := &renderState{h: h, t: t, w: w, name: layout}
+	t.Funcs(rs.funcs())
+	return &renderSet{t: t, rs: rs, pool: &e.sets}, nil
+}
+
+// layoutEntry returns the cache entry of the layout and view pair wired
+// from generation gen, adding it if needed
+func (h *HTMLTemplate) layoutEntry(layout, view string, gen uint64) *layoutEntry {
+	key := layout + "\x00" + view + "\x00" + strconv.FormatUint(gen, 10)
+
+	h.cacheMu.Lock()
+	defer h.cacheMu.Unlock()
+	e, ok := h.wired[key]
+	if !ok {
+		e = &layoutEntry{layout: layout, view: view}
+		h.wired[key] = e
+	}
+	return e
+}
+
+// InvalidateCache drops the cached layout wiring of every layout and view
+// pair that uses one of the named templates, directly or through the
+// templates it includes. With no names the whole cache is cleared. Sets in
+// use by renders in flight are discarded when those renders finish.
+func (h *HTMLTemplate) InvalidateCache(names ...string) {
+	h.mu.RLock()
+	deps := h.deps
+	h.mu.RUnlock()
+
+	h.cacheMu.Lock()
+	defer h.cacheMu.Unlock()
+
+	if len(names) == 0 {
+		clear(h.wired)
+		return
+	}
+
+	for key, e := range h.wired {
+		if dependsOn(deps, e.layout, names) || dependsOn(deps, e.view, names) {
+			delete(h.wired, key)
+		}
+	}
+}
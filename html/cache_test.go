@@ -0,0 +1,83 @@
+package html
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestLayoutCache(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":         `page {{partial "part.html"}}`,
+		"other.html":        `other`,
+		"part.html":         `part`,
+		"layouts/base.html": `<main>{{template "content" .}}</main>`,
+	}, WithLayoutDir("layouts"), WithCache(true))
+
+	render := func(view string) string {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := h.RenderWithLayout(&buf, &RenderData{Layout: "base", View: view}); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	for range 2 {
+		if got := render("page.html"); got != "<main>page part</main>" {
+			t.Fatalf("page got %q", got)
+		}
+		if got := render("other.html"); got != "<main>other</main>" {
+			t.Fatalf("other got %q", got)
+		}
+	}
+	if len(h.wired) != 2 {
+		t.Fatalf("%d wired pairs, want 2", len(h.wired))
+	}
+
+	// part.html is only reached from page.html
+	h.InvalidateCache("part.html")
+	if _, ok := h.wired["base\x00other.html\x00"+strconv.FormatUint(h.cfgGen, 10)]; !ok || len(h.wired) != 1 {
+		t.Fatalf("after invalidating part.html: %v", h.wired)
+	}
+	h.InvalidateCache()
+	if len(h.wired) != 0 {
+		t.Fatalf("after invalidating everything: %v", h.wired)
+	}
+	if got := render("page.html"); got != "<main>page part</main>" {
+		t.Fatalf("after invalidation got %q", got)
+	}
+}
+
+func TestLayoutCacheDuringReload(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":         `{{define "content"}}page{{end}}`,
+		"layouts/base.html": `<main>{{template "content" .}}</main>`,
+	}, WithLayoutDir("layouts"), WithCache(true), WithDevelopment(true))
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 20 {
+				if err := h.reload(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		})
+		wg.Go(func() {
+			for range 50 {
+				var buf bytes.Buffer
+				if err := h.RenderWithLayout(&buf, &RenderData{Layout: "base", View: "page.html"}); err != nil {
+					t.Error(err)
+					return
+				}
+				if got := buf.String(); got != "<main>page</main>" {
+					t.Errorf("got %q", got)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
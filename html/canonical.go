@@ -0,0 +1,125 @@
+package html
+
+import (
+	"bytes"
+	stdhtml "html"
+	"slices"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// RenderCanonical renders a template and returns its output in a canonical
+// form meant for snapshot and golden-file tests: one node per line,
+// indented by depth, attributes sorted by name, whitespace in text
+// collapsed and whitespace-only text dropped. Two renders that differ only
+// in attribute order or formatting produce identical output. The result is
+// not meant to be served; the whitespace changes can alter rendering.
+func (h *HTMLTemplate) RenderCanonical(name string, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := h.Render(&buf, name, data); err != nil {
+		return "", err
+	}
+	return canonicalHTML(buf.String())
+}
+
+// canonicalHTML parses src as a document, or as body content if it isn't a
+// whole document, and serializes it canonically
+func canonicalHTML(src string) (string, error) {
+	var nodes []*xhtml.Node
+
+	head := strings.ToLower(strings.TrimSpace(src))
+	if strings.HasPrefix(head, "<!doctype") || strings.HasPrefix(head, "<html") {
+		doc, err := xhtml.Parse(strings.NewReader(src))
+		if err != nil {
+			return "", err
+		}
+		for c := doc.FirstChild; c != nil; c = c.NextSibling {
+			nodes = append(nodes, c)
+		}
+	} else {
+		body := &xhtml.Node{Type: xhtml.ElementNode, Data: "body", DataAtom: atom.Body}
+		var err error
+		nodes, err = xhtml.ParseFragment(strings.NewReader(src), body)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var b strings.Builder
+	for _, n := range nodes {
+		writeCanonical(&b, n, 0)
+	}
+	return b.String(), nil
+}
+
+// rawTextElements keep their text exactly as rendered
+var rawTextElements = []string{"pre", "textarea", "script", "style"}
+
+// writeCanonical writes n and its children, one node per line
+func writeCanonical(b *strings.Builder, n *xhtml.Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	switch n.Type {
+	case xhtml.DoctypeNode:
+		b.WriteString(indent + "<!DOCTYPE " + n.Data + ">\n")
+
+	case xhtml.CommentNode:
+		b.WriteString(indent + "<!--" + strings.Join(strings.Fields(n.Data), " ") + "-->\n")
+
+	case xhtml.TextNode:
+		text := strings.Join(strings.Fields(n.Data), " ")
+		if text != "" {
+			b.WriteString(indent + stdhtml.EscapeString(text) + "\n")
+		}
+
+	case xhtml.ElementNode:
+		attrs := slices.Clone(n.Attr)
+		slices.SortFunc(attrs, func(a, b xhtml.Attribute) int {
+			return strings.Compare(a.Namespace+":"+a.Key, b.Namespace+":"+b.Key)
+		})
+
+		b.WriteString(indent + "<" + n.Data)
+		for _, a := range attrs {
+			key := a.Key
+			if a.Namespace != "" {
+				key = a.Namespace + ":" + key
+			}
+			b.WriteString(" " + key + `="` + stdhtml.EscapeString(a.Val) + `"`)
+		}
+		b.WriteString(">\n")
+
+		if isVoidElement(n.Data) {
+			return
+		}
+
+		if slices.Contains(rawTextElements, n.Data) {
+			var raw strings.Builder
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == xhtml.TextNode {
+					raw.WriteString(c.Data)
+				}
+			}
+			if raw.Len() > 0 {
+				b.WriteString(raw.String() + "\n")
+			}
+		} else {
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				writeCanonical(b, c, depth+1)
+			}
+		}
+
+		b.WriteString(indent + "</" + n.Data + ">\n")
+	}
+}
+
+// isVoidElement reports whether the element never has content or an end tag
+func isVoidElement(tag string) bool {
+	switch tag {
+	case "area", "base", "br", "col", "embed", "hr", "img", "input",
+		"link", "meta", "source", "track", "wbr":
+		return true
+	}
+	return false
+}
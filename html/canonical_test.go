@@ -0,0 +1,40 @@
+package html
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderCanonical(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"a.html": `<div class="x" id="main">  Hello
+   {{.}}  <br></div>`,
+		"b.html": "<div id=\"main\" class=\"x\">\n\tHello {{.}}\n<br>\n</div>\n",
+	})
+
+	a, err := h.RenderCanonical("a.html", "world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := h.RenderCanonical("b.html", "world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Fatalf("canonical forms differ:\n%s\n---\n%s", a, b)
+	}
+	want := "<div class=\"x\" id=\"main\">\n  Hello world\n  <br>\n</div>\n"
+	if a != want {
+		t.Fatalf("got %q, want %q", a, want)
+	}
+}
+
+func TestCanonicalHTMLRawText(t *testing.T) {
+	got, err := canonicalHTML("<!DOCTYPE html><html><head></head><body><pre>a\n  b</pre></body></html>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "a\n  b") || !strings.HasPrefix(got, "<!DOCTYPE html>") {
+		t.Fatalf("got %q, want the doctype and pre text kept", got)
+	}
+}
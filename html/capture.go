@@ -0,0 +1,32 @@
+package html
+
+import (
+	"fmt"
+	"html/template"
+)
+
+// capture renders the named template like partial and keeps its output
+// under key for captured, writing nothing where it is called:
+//
+//	{{capture "summary" "summary.html" .Article}}
+//	<meta name="description" content="{{captured "summary"}}">
+func (rs *renderState) capture(key, name string, data ...any) (string, error) {
+	out, err := rs.partial(name, data...)
+	if err != nil {
+		return "", fmt.Errorf("capture %s: %w", key, err)
+	}
+	if rs.captures == nil {
+		rs.captures = map[string]template.HTML{}
+	}
+	rs.captures[key] = out
+	return "", nil
+}
+
+// captured returns the output captured under key earlier in the render
+func (rs *renderState) captured(key string) (template.HTML, error) {
+	out, ok := rs.captures[key]
+	if !ok {
+		return "", fmt.Errorf("captured %s: nothing captured under that key", key)
+	}
+	return out, nil
+}
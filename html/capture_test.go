@@ -0,0 +1,27 @@
+package html
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	funcs, calls := counter()
+	h := newTestEngine(t, map[string]string{
+		"page.html":    `{{capture "summary" "summary.html" .}}<meta name="description" content="{{captured "summary"}}"><p>{{captured "summary"}}</p>`,
+		"summary.html": `{{.}} #{{count}}`,
+		"missing.html": `{{captured "nothing"}}`,
+	}, WithFuncs(funcs))
+
+	if got, want := renderString(t, h, "page.html", "Tom & Jerry"), `<meta name="description" content="Tom &amp; Jerry #1"><p>Tom &amp; Jerry #1</p>`; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if *calls != 1 {
+		t.Fatalf("captured template ran %d times, want once", *calls)
+	}
+
+	err := h.Render(&strings.Builder{}, "missing.html", nil)
+	if err == nil || !strings.Contains(err.Error(), "captured nothing: nothing captured under that key") {
+		t.Fatalf("got %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package html
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template/parse"
+)
+
+// CheckTemplates parses the templates matching pattern in dir and reports
+// every problem it finds in one pass: parse errors of each file and
+// references to undefined templates, located by file and line where
+// possible. It is meant for CI, from a tiny main or a go test.
+func CheckTemplates(dir, pattern string, opts ...Option) error {
+	cfg := Sparkle(pattern, append(opts, WithTemplateDir(dir))...).(*html).config
+
+	files, err := cfg.glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no templates match %s", filepath.Join(dir, pattern))
+	}
+
+	var errs []error
+	funcs := cfg.mergeFuncs()
+	if !cfg.FuncCheck {
+		maps.Copy(funcs, cfg.undefinedFuncs(pattern, funcs))
+	}
+	t := template.New("").Delims(cfg.Delimiters[0], cfg.Delimiters[1]).Funcs(funcs)
+
+	// Parse each file on its own so one broken file doesn't hide the rest
+	for _, file := range files {
+		src, err := cfg.readFile(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !cfg.tagged(cfg.fileTags(src)) {
+			continue
+		}
+		if _, err := t.New(filepath.Base(file)).Parse(string(src)); err != nil {
+			if conflicts := findDelimiterConflicts(file, src, cfg.Delimiters[0], cfg.Delimiters[1]); len(conflicts) > 0 {
+				err = fmt.Errorf("%w (possible delimiter conflict at %s)", err, conflicts[0])
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	if _, _, err := cfg.parseLayouts(t); err != nil {
+		errs = append(errs, err)
+	}
+
+	errs = append(errs, checkReferences(t)...)
+	errs = append(errs, checkFuncs(t, funcs)...)
+	return errors.Join(errs...)
+}
+
+// ValidateFuncs reports every function call in the templates that names a
+// function missing from the engine's function map. The parser already
+// rejects unknown functions in template source, but trees assembled by the
+// engine itself skip that check; this pass covers every tree before any
+// render reaches it.
+func (h *HTMLTemplate) ValidateFuncs() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return errors.Join(checkFuncs(h.t, h.config.mergeFuncs())...)
+}
+
+// ValidateReferences reports every {{template}} call that refers to a
+// template not defined in the set
+func (h *HTMLTemplate) ValidateReferences() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return errors.Join(checkReferences(h.t)...)
+}
+
+// FunctionsUsed returns the sorted names of the functions the named
+// template calls, predefined ones included, along with those called by
+// every template it includes through {{template}} or an include helper
+// with a literal name
+func (h *HTMLTemplate) FunctionsUsed(name string) ([]string, error) {
+	name = h.resolve(name)
+	if err := h.validateTemplate(name); err != nil {
+		return nil, err
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var used []string
+	for _, dep := range reachable(h.deps, name) {
+		tpl := h.base.Lookup(dep)
+		if tpl == nil || tpl.Tree == nil {
+			continue
+		}
+		walkTree(tpl.Tree.Root, func(node parse.Node) bool {
+			if n, ok := node.(*parse.IdentifierNode); ok {
+				used = append(used, n.Ident)
+			}
+			return true
+		})
+	}
+
+	slices.Sort(used)
+	return slices.Compact(used), nil
+}
+
+// checkReferences returns an error for each {{template}} call in t that
+// names an undefined template. The "content" block is defined at render
+// time by RenderWithLayout and is not reported.
+func checkReferences(t *template.Template) []error {
+	var errs []error
+	for _, tpl := range t.Templates() {
+		if tpl.Tree == nil {
+			continue
+		}
+		tree := tpl.Tree
+		walkTree(tree.Root, func(node parse.Node) bool {
+			n, ok := node.(*parse.TemplateNode)
+			if !ok || n.Name == "content" || t.Lookup(n.Name) != nil {
+				return true
+			}
+			location, _ := tree.ErrorContext(n)
+			errs = append(errs, fmt.Errorf("%s: template %s references undefined template %q", location, tpl.Name(), n.Name))
+			return true
+		})
+	}
+	return errs
+}
+
+// builtinFuncs are the functions text/template predefines
+var builtinFuncs = []string{
+	"and", "call", "html", "index", "slice", "js", "len", "not", "or",
+	"print", "printf", "println", "urlquery",
+	"eq", "ge", "gt", "le", "lt", "ne",
+}
+
+// checkFuncs returns an error for each function call in t naming a
+// function that is neither in funcs nor predefined
+func checkFuncs(t *template.Template, funcs template.FuncMap) []error {
+	var errs []error
+	for _, tpl := range t.Templates() {
+		if tpl.Tree == nil {
+			continue
+		}
+		errs = append(errs, checkTreeFuncs(tpl.Name(), tpl.Tree, funcs)...)
+	}
+	return errs
+}
+
+// checkTreeFuncs is checkFuncs for a single tree
+func checkTreeFuncs(name string, tree *parse.Tree, funcs template.FuncMap) []error {
+	var errs []error
+	walkTree(tree.Root, func(node parse.Node) bool {
+		n, ok := node.(*parse.IdentifierNode)
+		if !ok || funcs[n.Ident] != nil || slices.Contains(builtinFuncs, n.Ident) || strings.HasPrefix(n.Ident, "_html_template_") {
+			return true
+		}
+		location, _ := tree.ErrorContext(n)
+		errs = append(errs, fmt.Errorf("%s: template %s calls undefined function %q", location, name, n.Ident))
+		return true
+	})
+	return errs
+}
+
+// undefinedFuncs returns a stand-in for each function called by the
+// template files, layouts included, and the inline sources that funcs
+// lacks, so they parse without the function check and fail only when a
+// render reaches the call
+func (c *Config) undefinedFuncs(pattern string, funcs template.FuncMap) template.FuncMap {
+	files, _ := c.glob(filepath.Join(c.TemplateDir, pattern))
+	if c.LayoutDir != "" {
+		layouts, _ := c.glob(filepath.Join(c.TemplateDir, c.LayoutDir, "*"))
+		files = append(files, layouts...)
+	}
+
+	stubs := template.FuncMap{}
+	for _, file := range files {
+		if src, err := c.readFile(file); err == nil {
+			c.stubFuncs(stubs, file, string(src), funcs)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.inline)) {
+		c.stubFuncs(stubs, name, c.inline[name], funcs)
+	}
+	return stubs
+}
+
+// stubFuncs adds to stubs a stand-in for each function called by the
+// template source src that funcs lacks
+func (c *Config) stubFuncs(stubs template.FuncMap, name, src string, funcs template.FuncMap) {
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	trees := map[string]*parse.Tree{}
+	if _, err := tree.Parse(src, c.Delimiters[0], c.Delimiters[1], trees); err != nil {
+		return // reported by the real parse
+	}
+
+	for _, tree := range trees {
+		walkTree(tree.Root, func(node parse.Node) bool {
+			n, ok := node.(*parse.IdentifierNode)
+			if !ok || funcs[n.Ident] != nil || slices.Contains(builtinFuncs, n.Ident) {
+				return true
+			}
+			name := n.Ident
+			stubs[name] = func(...any) (any, error) {
+				return nil, fmt.Errorf("function %q not defined", name)
+			}
+			return true
+		})
+	}
+}
+
+// warnDeprecated logs, in development, each call in t to a function marked
+// deprecated with WithDeprecatedFuncs, with its location and replacement
+func (c *Config) warnDeprecated(t *template.Template) {
+	if !c.Development || len(c.DeprecatedFuncs) == 0 {
+		return
+	}
+	tpls := t.Templates()
+	slices.SortFunc(tpls, func(a, b *template.Template) int { return strings.Compare(a.Name(), b.Name()) })
+
+	for _, tpl := range tpls {
+		if tpl.Tree == nil {
+			continue
+		}
+		tree := tpl.Tree
+		walkTree(tree.Root, func(node parse.Node) bool {
+			n, ok := node.(*parse.IdentifierNode)
+			if !ok {
+				return true
+			}
+			if msg, ok := c.DeprecatedFuncs[n.Ident]; ok {
+				location, _ := tree.ErrorContext(n)
+				log.Printf("%s: template %s calls deprecated function %q: %s", location, tpl.Name(), n.Ident, msg)
+			}
+			return true
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package html
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"slices"
+	"strings"
+	"testing"
+	"text/template/parse"
+)
+
+func TestParseTimeFuncCheck(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"page.html": `{{if .}}{{missing}}{{end}}ok`})
+
+	if _, err := Sparkle("*.html", WithTemplateDir(dir)).CreateEngine(); err == nil || !strings.Contains(err.Error(), `"missing" not defined`) {
+		t.Fatalf("got %v, want a startup error", err)
+	}
+
+	engine, err := Sparkle("*.html", WithTemplateDir(dir), WithParseTimeFuncCheck(false)).CreateEngine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := engine.(*HTMLTemplate)
+	if got := renderString(t, h, "page.html", false); got != "ok" {
+		t.Fatalf("got %q", got)
+	}
+	var buf bytes.Buffer
+	if err := h.Render(&buf, "page.html", true); err == nil || !strings.Contains(err.Error(), `function "missing" not defined`) {
+		t.Fatalf("got %v, want the call to fail", err)
+	}
+}
+
+func TestLaxFuncCheckRuntimeTemplates(t *testing.T) {
+	h := newTestEngine(t, map[string]string{"page.html": `page`}, WithParseTimeFuncCheck(false))
+
+	if err := h.AddTemplate("plugin", `{{if .}}{{pluginOnly}}{{end}}plugin`); err != nil {
+		t.Fatalf("AddTemplate: %v", err)
+	}
+	if got := renderString(t, h, "plugin", false); got != "plugin" {
+		t.Fatalf("got %q", got)
+	}
+
+	type badge struct {
+		Tmpl string `tmpl:"badge"`
+	}
+	if err := h.RegisterStructTemplates(badge{Tmpl: `{{if .}}{{badgeOnly}}{{end}}badge`}); err != nil {
+		t.Fatalf("RegisterStructTemplates: %v", err)
+	}
+	if got := renderString(t, h, "badge", false); got != "badge" {
+		t.Fatalf("got %q", got)
+	}
+
+	// Both sources are parsed again with their stubs on reload
+	if err := h.reload(); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := h.Render(&buf, "plugin", true); err == nil || !strings.Contains(err.Error(), `"pluginOnly" not defined`) {
+		t.Fatalf("got %v, want the call to fail", err)
+	}
+}
+
+func TestCheckTemplatesLaxFuncs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"page.html": `{{missing}}`})
+
+	if err := CheckTemplates(dir, "*.html"); err == nil || !strings.Contains(err.Error(), `"missing" not defined`) {
+		t.Fatalf("got %v, want the undefined function", err)
+	}
+	if err := CheckTemplates(dir, "*.html", WithParseTimeFuncCheck(false)); err != nil {
+		t.Fatalf("lax: %v", err)
+	}
+}
+
+func TestCheckTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"ok.html":      `{{template "footer.html"}}`,
+		"footer.html":  `footer`,
+		"broken.html":  `{{if}}`,
+		"missing.html": `{{template "nowhere.html"}}`,
+	})
+	err := CheckTemplates(dir, "*.html")
+	if err == nil {
+		t.Fatal("want errors")
+	}
+	for _, want := range []string{"broken.html", `undefined template "nowhere.html"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q lacks %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "ok.html") {
+		t.Errorf("error %q reports ok.html", err)
+	}
+}
+
+func TestValidateFuncs(t *testing.T) {
+	h := newTestEngine(t, map[string]string{"page.html": `{{upper "a"}}{{len .}}`},
+		WithFuncs(template.FuncMap{"upper": strings.ToUpper}))
+	if err := h.ValidateFuncs(); err != nil {
+		t.Fatalf("got %v", err)
+	}
+
+	// Lax engines parse unknown functions; ValidateFuncs still finds them
+	h = newTestEngine(t, map[string]string{"page.html": "x\n{{if .}}{{ghost 1}}{{end}}"},
+		WithParseTimeFuncCheck(false))
+	err := h.ValidateFuncs()
+	if err == nil || !strings.Contains(err.Error(), `page.html:2:`) || !strings.Contains(err.Error(), `template page.html calls undefined function "ghost"`) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestCheckTreeFuncs(t *testing.T) {
+	tree := parse.New("t")
+	tree.Mode = parse.SkipFuncCheck
+	if _, err := tree.Parse(`{{known}}{{printf "%d" (missing 1)}}{{with .}}{{gone}}{{end}}`, "", "", map[string]*parse.Tree{}); err != nil {
+		t.Fatal(err)
+	}
+	errs := checkTreeFuncs("t", tree, template.FuncMap{"known": func() string { return "" }})
+	if len(errs) != 2 || !strings.Contains(errs[0].Error(), `"missing"`) || !strings.Contains(errs[1].Error(), `"gone"`) {
+		t.Fatalf("got %v", errs)
+	}
+}
+
+func TestFunctionsUsed(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":   `<h1>{{upper .Title}}</h1>{{template "head.html" .}}{{partial "nav.html"}}`,
+		"head.html":   `<link href="{{asset "app.css"}}">`,
+		"nav.html":    `{{range $i, $l := .}}{{len $l}}{{end}}`,
+		"unused.html": `{{humanBytes 1}}`,
+	}, WithAssetDir(t.TempDir()), WithFuncs(template.FuncMap{"upper": strings.ToUpper}))
+
+	got, err := h.FunctionsUsed("page.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"asset", "len", "partial", "upper"}; !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, err := h.FunctionsUsed("missing.html"); err == nil {
+		t.Fatal("missing template: want an error")
+	}
+}
+
+func TestDeprecatedFuncs(t *testing.T) {
+	files := map[string]string{
+		"page.html": "<p>\n{{oldDate .}}</p>",
+		"ok.html":   `{{newDate .}}`,
+	}
+	funcs := template.FuncMap{"oldDate": fmt.Sprint, "newDate": fmt.Sprint}
+	deprecated := WithDeprecatedFuncs(map[string]string{"oldDate": "use newDate instead"})
+
+	logs := captureLogs(t)
+	newTestEngine(t, files, WithFuncs(funcs), deprecated, WithDevelopment(true))
+	want := `page.html:2:2: template page.html calls deprecated function "oldDate": use newDate instead`
+	if !strings.Contains(logs.String(), want) {
+		t.Fatalf("no %q in %q", want, logs.String())
+	}
+	if strings.Contains(logs.String(), "ok.html") {
+		t.Fatalf("ok.html reported in %q", logs.String())
+	}
+
+	logs.Reset()
+	newTestEngine(t, files, WithFuncs(funcs), deprecated)
+	if strings.Contains(logs.String(), "deprecated function") {
+		t.Fatalf("production logged %q", logs.String())
+	}
+}
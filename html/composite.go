@@ -0,0 +1,95 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"slices"
+)
+
+// SectionSpec names the template and data of one region of a composite
+// page
+type SectionSpec struct {
+	Template string
+	Data     any
+}
+
+// SectionError reports a section of a composite page that failed to render
+type SectionError struct {
+	Section  string
+	Template string
+	Err      error
+}
+
+func (e *SectionError) Error() string {
+	return fmt.Sprintf("section %s (template %s): %v", e.Section, e.Template, e.Err)
+}
+
+func (e *SectionError) Unwrap() error {
+	return e.Err
+}
+
+// RenderComposite renders each section like a partial, then renders layout
+// with the results as .Sections. A failed section aborts the page unless
+// WithLaxSections is set, when it is left empty and reported afterwards.
+func (h *HTMLTemplate) RenderComposite(w io.Writer, layout string, sections map[string]SectionSpec) error {
+	if h.config.Development {
+		if err := h.reloadIfNeeded(); err != nil {
+			return fmt.Errorf("failed to reload templates: %w", err)
+		}
+	}
+
+	layout = h.resolve(layout)
+	if err := h.validateTemplate(layout); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	rendered := make(map[string]template.HTML, len(sections))
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(sections)) {
+		spec := sections[name]
+		out, err := h.renderSection(ctx, spec)
+		if err != nil {
+			err = &SectionError{Section: name, Template: spec.Template, Err: err}
+			if !h.config.LaxSections {
+				return err
+			}
+			errs = append(errs, err)
+			continue
+		}
+		rendered[name] = out
+	}
+
+	data, err := h.config.prepare(layout, map[string]any{"Sections": rendered})
+	if err != nil {
+		return err
+	}
+	if err := h.execute(ctx, w, layout, data); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// renderSection renders one section of a composite page
+func (h *HTMLTemplate) renderSection(ctx context.Context, spec SectionSpec) (template.HTML, error) {
+	spec.Template = h.resolve(spec.Template)
+	if err := h.validateTemplate(spec.Template); err != nil {
+		return "", err
+	}
+
+	data, err := h.config.transform(spec.Template, spec.Data)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := h.execute(ctx, &buf, spec.Template, data); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
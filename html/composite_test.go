@@ -0,0 +1,67 @@
+package html
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+var compositeFiles = map[string]string{
+	"dash.html":   `<aside>{{.Sections.side}}</aside><main>{{.Sections.main}}</main>{{.Year}}`,
+	"menu.html":   `menu {{.}}`,
+	"chart.html":  `chart {{.}}`,
+	"broken.html": `{{.Missing.Field}}`,
+}
+
+func TestRenderComposite(t *testing.T) {
+	var hooked []string
+	h := newTestEngine(t, compositeFiles,
+		WithGlobals(map[string]any{"Year": 2024}),
+		WithBeforeRender(func(name string, data any) (any, error) {
+			hooked = append(hooked, name)
+			return data, nil
+		}))
+
+	var buf bytes.Buffer
+	err := h.RenderComposite(&buf, "dash.html", map[string]SectionSpec{
+		"side": {Template: "menu.html", Data: 1},
+		"main": {Template: "chart.html", Data: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "<aside>menu 1</aside><main>chart 2</main>2024" {
+		t.Fatalf("got %q", got)
+	}
+	if len(hooked) != 1 || hooked[0] != "dash.html" {
+		t.Fatalf("BeforeRender called for %q, want once for the layout", hooked)
+	}
+}
+
+func TestRenderCompositeSectionError(t *testing.T) {
+	sections := map[string]SectionSpec{
+		"side": {Template: "menu.html", Data: 1},
+		"main": {Template: "broken.html", Data: 2},
+	}
+
+	h := newTestEngine(t, compositeFiles)
+	var buf bytes.Buffer
+	err := h.RenderComposite(&buf, "dash.html", sections)
+	var serr *SectionError
+	if !errors.As(err, &serr) || serr.Section != "main" || serr.Template != "broken.html" {
+		t.Fatalf("got %v, want a SectionError for main", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("wrote %q before failing", buf.String())
+	}
+
+	h = newTestEngine(t, compositeFiles, WithLaxSections(true))
+	buf.Reset()
+	err = h.RenderComposite(&buf, "dash.html", sections)
+	if !errors.As(err, &serr) || serr.Section != "main" {
+		t.Fatalf("lax: got %v, want a SectionError for main", err)
+	}
+	if got := buf.String(); got != "<aside>menu 1</aside><main></main>" {
+		t.Fatalf("lax: got %q", got)
+	}
+}
@@ -0,0 +1,92 @@
+package html
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+)
+
+// checkConfig looks for options that have no effect or contradict each
+// other. With WithStrictConfig the problems fail engine creation; otherwise
+// each is logged as a warning.
+func (h *HTMLTemplate) checkConfig() error {
+	problems := h.configProblems()
+	if len(problems) == 0 {
+		return nil
+	}
+	if h.config.StrictConfig {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(problems...))
+	}
+	for _, p := range problems {
+		log.Printf("Template config: %v", p)
+	}
+	return nil
+}
+
+// configProblems returns the dead and conflicting options of the engine's
+// config, such as dev-only options in production or unknown template names
+func (h *HTMLTemplate) configProblems() []error {
+	c := h.config
+	var problems []error
+
+	if !c.Development {
+		devOnly := []struct {
+			option string
+			set    bool
+		}{
+			{"WithStrictFuncs", c.StrictFuncs},
+			{"WithRenderHeaders", c.RenderHeaders},
+			{"WithOutputCheck", c.CheckOutput},
+			{"WithAMPCheck", c.CheckAMP},
+			{"WithDeprecatedFuncs", len(c.DeprecatedFuncs) > 0},
+			{"WithWatchDebounce", c.WatchDebounce > 0},
+			{"WithWatchIgnore", len(c.WatchIgnore) > 0},
+		}
+		for _, o := range devOnly {
+			if o.set {
+				problems = append(problems, fmt.Errorf("%s has no effect outside development mode", o.option))
+			}
+		}
+	}
+
+	if c.AutoAssetVersion && c.AssetVersion != "" {
+		problems = append(problems, errors.New("WithAssetVersion is ignored for assets WithAutoAssetVersion can hash"))
+	}
+	if c.OutputBOM && c.Charset != "" && !strings.EqualFold(c.Charset, "utf-8") {
+		problems = append(problems, fmt.Errorf("WithOutputBOM writes a UTF-8 byte order mark but the charset is %s", c.Charset))
+	}
+	if c.LayoutByDefault && c.DefaultLayout == "" && len(c.LayoutRules) == 0 {
+		problems = append(problems, errors.New("WithLayoutByDefault has no effect without a default layout or layout rules"))
+	}
+	for _, t := range c.OutputTransforms {
+		if _, err := path.Match(t.Pattern, ""); err != nil {
+			problems = append(problems, fmt.Errorf("WithTransformFor %q: %w", t.Pattern, err))
+		}
+	}
+	if c.MaxDepth < 1 {
+		problems = append(problems, fmt.Errorf("max render depth %d fails every partial", c.MaxDepth))
+	}
+
+	missing := func(option, name string) {
+		if name != "" && !h.HasTemplate(name) {
+			problems = append(problems, fmt.Errorf("%s: template %s not found", option, name))
+		}
+	}
+	missing("WithNotFoundTemplate", c.NotFound)
+	missing("WithPanicTemplate", c.PanicTemplate)
+	missing("WithErrorPlaceholder", c.ErrorPlaceholder)
+	for _, name := range c.Unescaped {
+		missing("WithUnescapedTemplates", name)
+	}
+	for _, name := range c.NoMemo {
+		missing("WithNoMemo", name)
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.DataTransforms)) {
+		missing("WithDataTransform", name)
+	}
+	return problems
+}
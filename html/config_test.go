@@ -0,0 +1,48 @@
+package html
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStrictConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"page.html": `page`})
+
+	tests := []struct {
+		opts []Option
+		want string
+	}{
+		{[]Option{WithRenderHeaders(true)}, "WithRenderHeaders has no effect outside development mode"},
+		{[]Option{WithPanicTemplate("oops.html")}, "WithPanicTemplate: template oops.html not found"},
+		{[]Option{WithAutoAssetVersion(true), WithAssetVersion("3")}, "WithAssetVersion is ignored"},
+		{[]Option{WithOutputBOM(true), WithCharset("ISO-8859-1")}, "the charset is ISO-8859-1"},
+		{[]Option{WithLayoutByDefault(true)}, "WithLayoutByDefault has no effect"},
+		{[]Option{WithTransformFor("[", nil)}, `WithTransformFor "["`},
+	}
+	for _, tt := range tests {
+		opts := append([]Option{WithTemplateDir(dir), WithStrictConfig(true)}, tt.opts...)
+		_, err := Sparkle("*.html", opts...).CreateEngine()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("got %v, want %q", err, tt.want)
+		}
+	}
+
+	if _, err := Sparkle("*.html", WithTemplateDir(dir), WithStrictConfig(true), WithDevelopment(true), WithRenderHeaders(true)).CreateEngine(); err != nil {
+		t.Fatalf("valid config got %v", err)
+	}
+}
+
+func TestConfigWarnings(t *testing.T) {
+	logs := captureLogs(t)
+	newTestEngine(t, map[string]string{"page.html": `page`}, WithRenderHeaders(true), WithNoMemo("card.html"))
+
+	for _, want := range []string{
+		"Template config: WithRenderHeaders has no effect outside development mode",
+		"Template config: WithNoMemo: template card.html not found",
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("no %q in %q", want, logs.String())
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package html
+
+import (
+	"fmt"
+	"maps"
+)
+
+// mergeValues merges page data over base values into a fresh map, so
+// neither is modified. Maps and structs are flattened as by mergeData, so
+// templates see the same fields whether or not base values are merged in.
+// Other data has no keys to merge and is kept whole under the "Data" key.
+func mergeValues(base map[string]any, data any) map[string]any {
+	merged := make(map[string]any, len(base)+1)
+	maps.Copy(merged, base)
+	if err := mergeInto(merged, data); err != nil {
+		merged["Data"] = data
+	}
+	return merged
+}
+
+// withGlobals merges the configured globals under data
+func (c *Config) withGlobals(data any) any {
+	if len(c.Globals) == 0 {
+		return data
+	}
+	return mergeValues(c.Globals, data)
+}
+
+// prepare merges the globals under data and passes the result through the
+// BeforeRender hook, the last step before name executes
+func (c *Config) prepare(name string, data any) (any, error) {
+	data = c.withGlobals(data)
+	if c.BeforeRender == nil {
+		return data, nil
+	}
+	data, err := c.BeforeRender(name, data)
+	if err != nil {
+		return nil, fmt.Errorf("before render %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// transform applies the data transform registered for name, if any
+func (c *Config) transform(name string, data any) (any, error) {
+	fn, ok := c.DataTransforms[name]
+	if !ok {
+		return data, nil
+	}
+	data, err := fn(data)
+	if err != nil {
+		return nil, fmt.Errorf("transform data for %s: %w", name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,185 @@
+package html
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func upperName(data any) (any, error) {
+	m := data.(map[string]any)
+	return map[string]any{"Name": strings.ToUpper(m["Name"].(string))}, nil
+}
+
+func TestDataTransform(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":  `{{.Name}}|{{partial "card.html" .}}`,
+		"card.html":  `{{.Name}}`,
+		"plain.html": `{{.Name}}`,
+	}, WithDataTransform("page.html", upperName))
+
+	data := map[string]any{"Name": "mofu"}
+	if got := renderString(t, h, "page.html", data); got != "MOFU|MOFU" {
+		t.Fatalf("got %q", got)
+	}
+	if got := renderString(t, h, "plain.html", data); got != "mofu" {
+		t.Fatalf("untransformed template got %q", got)
+	}
+}
+
+func TestDataTransformError(t *testing.T) {
+	errBad := errors.New("bad data")
+	h := newTestEngine(t, map[string]string{
+		"page.html": `page`,
+	}, WithDataTransform("page.html", func(any) (any, error) { return nil, errBad }))
+
+	var buf bytes.Buffer
+	if err := h.Render(&buf, "page.html", nil); !errors.Is(err, errBad) {
+		t.Fatalf("got %v, want %v", err, errBad)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("wrote %q before failing", buf.String())
+	}
+}
+
+func TestDataTransformRenderVariants(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{.Name}}`,
+	}, WithDataTransform("page.html", upperName))
+	data := map[string]any{"Name": "mofu"}
+
+	var buf bytes.Buffer
+	if err := h.RenderInTimezone(&buf, "page.html", data, time.UTC); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "MOFU" {
+		t.Fatalf("RenderInTimezone got %q", buf.String())
+	}
+
+	buf.Reset()
+	trace, err := h.RenderTraced(&buf, "page.html", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "MOFU" {
+		t.Fatalf("RenderTraced got %q", buf.String())
+	}
+	if trace.Name != "page.html" {
+		t.Fatalf("trace root %q", trace.Name)
+	}
+}
+
+func TestRenderVariantsReload(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `old`,
+	}, WithDevelopment(true))
+	writeFiles(t, h.config.TemplateDir, map[string]string{"page.html": `new`})
+	h.lastLoad = time.Time{}
+
+	var buf bytes.Buffer
+	if err := h.RenderInTimezone(&buf, "page.html", nil, time.UTC); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "new" {
+		t.Fatalf("RenderInTimezone got %q, want the reloaded template", buf.String())
+	}
+
+	writeFiles(t, h.config.TemplateDir, map[string]string{"page.html": `newer`})
+	h.lastLoad = time.Time{}
+	buf.Reset()
+	if _, err := h.RenderTraced(&buf, "page.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "newer" {
+		t.Fatalf("RenderTraced got %q, want the reloaded template", buf.String())
+	}
+}
+
+func TestRenderInTimezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip(err)
+	}
+	h := newTestEngine(t, map[string]string{
+		"date.html": `{{formatDate "15:04" .}}`,
+	}, WithTimezone(time.UTC))
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := h.RenderInTimezone(&buf, "date.html", at, tokyo); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "21:00" {
+		t.Fatalf("got %q, want 21:00", buf.String())
+	}
+	if got := renderString(t, h, "date.html", at); got != "12:00" {
+		t.Fatalf("later render got %q, want the configured timezone", got)
+	}
+}
+
+func TestGlobals(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{.Site}} {{.Title}} {{.Data}}`,
+		"nil.html":  `{{if .}}{{.Site}}{{else}}nil{{end}}`,
+	}, WithGlobals(map[string]any{"Site": "mofu", "Title": "default"}))
+
+	if got := renderString(t, h, "page.html", map[string]any{"Title": "home"}); got != "mofu home " {
+		t.Fatalf("map data got %q", got)
+	}
+	if got := renderString(t, h, "page.html", 42); got != "mofu default 42" {
+		t.Fatalf("non-map data got %q", got)
+	}
+	if got := renderString(t, h, "nil.html", nil); got != "mofu" {
+		t.Fatalf("nil data got %q", got)
+	}
+	page := struct{ Title string }{"about"}
+	if got := renderString(t, h, "page.html", page); got != "mofu about " {
+		t.Fatalf("struct data got %q, want its fields beside the globals", got)
+	}
+	if got := renderString(t, h, "page.html", &page); got != "mofu about " {
+		t.Fatalf("struct pointer data got %q", got)
+	}
+
+	// Globals are merged into a fresh map, leaving the caller's untouched
+	data := map[string]any{"Title": "x"}
+	renderString(t, h, "page.html", data)
+	if len(data) != 1 {
+		t.Fatalf("page data modified: %v", data)
+	}
+
+	h = newTestEngine(t, map[string]string{"nil.html": `{{if .}}set{{else}}nil{{end}}`})
+	if got := renderString(t, h, "nil.html", nil); got != "nil" {
+		t.Fatalf("nil data without globals got %q", got)
+	}
+}
+
+func TestBeforeRender(t *testing.T) {
+	var names []string
+	hook := func(name string, data any) (any, error) {
+		names = append(names, name)
+		m := data.(map[string]any)
+		if m["Fail"] == true {
+			return nil, errors.New("denied")
+		}
+		m["Year"] = 2024
+		return m, nil
+	}
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{.Site}} {{.Year}} {{partial "foot.html" .}}`,
+		"foot.html": `[{{.Year}}]`,
+	}, WithGlobals(map[string]any{"Site": "mofu"}), WithBeforeRender(hook))
+
+	if got := renderString(t, h, "page.html", map[string]any{}); got != "mofu 2024 [2024]" {
+		t.Fatalf("got %q", got)
+	}
+	if len(names) != 1 || names[0] != "page.html" {
+		t.Fatalf("hook ran for %v, want the page only", names)
+	}
+
+	err := h.Render(&bytes.Buffer{}, "page.html", map[string]any{"Fail": true})
+	if err == nil || err.Error() != "before render page.html: denied" {
+		t.Fatalf("got %v", err)
+	}
+}
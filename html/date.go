@@ -0,0 +1,74 @@
+package html
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// locations caches loaded timezones by name
+var locations sync.Map
+
+// location returns the timezone date helpers render in
+func (c *Config) location() *time.Location {
+	if c.Location != nil {
+		return c.Location
+	}
+	return time.Local
+}
+
+// location returns the timezone of this render
+func (rs *renderState) location() *time.Location {
+	if rs.loc != nil {
+		return rs.loc
+	}
+	return rs.h.config.location()
+}
+
+// loadLocation loads a timezone by IANA name, caching the result
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// formatDate formats t with layout in loc
+func formatDate(layout string, t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(layout)
+}
+
+// inTZ converts t to the named timezone. An unknown name leaves t in the
+// configured timezone rather than failing the render.
+func (c *Config) inTZ(t time.Time, name string) time.Time {
+	return c.inTZOr(t, name, c.location())
+}
+
+// inTZOr is inTZ falling back to fallback for unknown names
+func (c *Config) inTZOr(t time.Time, name string, fallback *time.Location) time.Time {
+	loc, err := loadLocation(name)
+	if err != nil {
+		if c.Development {
+			log.Printf("inTZ: unknown timezone %q, using %s", name, fallback)
+		}
+		return t.In(fallback)
+	}
+	return t.In(loc)
+}
+
+// locKey is the context key of the timezone set by RenderInTimezone
+type locKey struct{}
+
+// RenderInTimezone renders a template with date helpers using loc instead
+// of the configured timezone, typically the timezone of the current user
+func (h *HTMLTemplate) RenderInTimezone(w io.Writer, name string, data any, loc *time.Location) error {
+	return h.RenderContext(context.WithValue(context.Background(), locKey{}, loc), w, name, data)
+}
@@ -0,0 +1,20 @@
+package html
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateHelpers(t *testing.T) {
+	if _, err := time.LoadLocation("Asia/Tokyo"); err != nil {
+		t.Skip(err)
+	}
+	h := newTestEngine(t, map[string]string{
+		"date.html": `{{formatDate "15:04" .}} {{(inTZ . "Asia/Tokyo").Format "15:04"}} {{(inTZ . "Nowhere/City").Format "15:04"}}`,
+	}, WithTimezone(time.FixedZone("UTC+2", 2*3600)))
+
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if got := renderString(t, h, "date.html", at); got != "14:00 21:00 14:00" {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,72 @@
+package html
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// deadlineWriter fails writes once its deadline has passed or its context
+// is done, which aborts the template execution writing through it
+type deadlineWriter struct {
+	w        io.Writer
+	ctx      context.Context
+	deadline time.Time
+}
+
+func (d *deadlineWriter) Write(p []byte) (int, error) {
+	if err := d.ctx.Err(); err != nil {
+		return 0, fmt.Errorf("render aborted: %w", err)
+	}
+	if !d.deadline.IsZero() && !time.Now().Before(d.deadline) {
+		return 0, fmt.Errorf("render aborted: %w", os.ErrDeadlineExceeded)
+	}
+	return d.w.Write(p)
+}
+
+// writeDeadliner is implemented by net.Conn and similar writers
+type writeDeadliner interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// withDeadline bounds writes to w by the WriteTimeout and the deadline and
+// cancellation of ctx. When w, or the connection behind an
+// http.ResponseWriter, supports write deadlines, the deadline is also set
+// there so a write blocked on a slow client is interrupted. The returned
+// function clears that deadline and must be called once the render ends.
+func (h *HTMLTemplate) withDeadline(ctx context.Context, w io.Writer) (io.Writer, func()) {
+	var deadline time.Time
+	if h.config.WriteTimeout > 0 {
+		deadline = time.Now().Add(h.config.WriteTimeout)
+	}
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	if deadline.IsZero() && ctx.Done() == nil {
+		return w, func() {}
+	}
+
+	var conn writeDeadliner
+	switch w := w.(type) {
+	case writeDeadliner:
+		conn = w
+	case http.ResponseWriter:
+		conn = http.NewResponseController(w)
+	}
+
+	restore := func() {}
+	if conn != nil && !deadline.IsZero() {
+		if err := conn.SetWriteDeadline(deadline); err == nil {
+			restore = func() { conn.SetWriteDeadline(time.Time{}) }
+		} else if !errors.Is(err, http.ErrNotSupported) && h.config.Development {
+			log.Printf("Failed to set write deadline: %v", err)
+		}
+	}
+
+	return &deadlineWriter{w: w, ctx: ctx, deadline: deadline}, restore
+}
@@ -0,0 +1,51 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"os"
+	"testing"
+	"time"
+)
+
+// deadlineBuffer records the write deadlines set on it
+type deadlineBuffer struct {
+	bytes.Buffer
+	deadlines []time.Time
+}
+
+func (d *deadlineBuffer) SetWriteDeadline(t time.Time) error {
+	d.deadlines = append(d.deadlines, t)
+	return nil
+}
+
+func TestWriteTimeout(t *testing.T) {
+	h := newTestEngine(t, map[string]string{"page.html": `a{{pause}}b`},
+		WithFuncs(template.FuncMap{"pause": func() string { time.Sleep(30 * time.Millisecond); return "" }}),
+		WithWriteTimeout(10*time.Millisecond))
+
+	var w deadlineBuffer
+	err := h.Render(&w, "page.html", nil)
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("got %v, want a deadline error", err)
+	}
+	if w.String() != "a" {
+		t.Fatalf("got %q, want the output before the deadline", w.String())
+	}
+	if len(w.deadlines) != 2 || w.deadlines[0].IsZero() || !w.deadlines[1].IsZero() {
+		t.Fatalf("deadlines %v, want one set and cleared", w.deadlines)
+	}
+}
+
+func TestRenderContextCancelled(t *testing.T) {
+	h := newTestEngine(t, map[string]string{"page.html": `page`})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+	if err := h.RenderContext(ctx, &buf, "page.html", nil); !errors.Is(err, context.Canceled) || buf.Len() != 0 {
+		t.Fatalf("got %q, %v, want the render aborted", buf.String(), err)
+	}
+}
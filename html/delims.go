@@ -0,0 +1,143 @@
+package html
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log"
+	"strings"
+)
+
+// DelimiterConflict describes a delimiter occurrence in template source
+// that does not look like the start of a template action.
+type DelimiterConflict struct {
+	File   string
+	Line   int
+	Text   string
+	Reason string
+}
+
+func (d DelimiterConflict) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", d.File, d.Line, d.Reason, d.Text)
+}
+
+// parseGlob parses the files matching pattern. In development mode files
+// are scanned for delimiter conflicts first and any findings are logged;
+// when parsing fails the error points at the first conflict found, which
+// is usually the real culprit behind a confusing parse error.
+func (c *Config) parseGlob(t *template.Template, pattern string) (*template.Template, error) {
+	var conflicts []DelimiterConflict
+	if c.Development {
+		conflicts = c.scanDelimiters(pattern)
+		for _, d := range conflicts {
+			log.Printf("Template delimiter conflict at %s", d)
+		}
+	}
+
+	files, err := c.globTagged(pattern)
+	if err == nil && len(files) > 0 {
+		t, err = c.parseFiles(t, files)
+	}
+	if err != nil {
+		if conflicts == nil {
+			conflicts = c.scanDelimiters(pattern)
+		}
+		if len(conflicts) > 0 {
+			return nil, fmt.Errorf("%w (possible delimiter conflict at %s)", err, conflicts[0])
+		}
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// scanDelimiters reports delimiter conflicts in the files matching pattern.
+// Unreadable files are skipped; ParseGlob reports them properly.
+func (c *Config) scanDelimiters(pattern string) []DelimiterConflict {
+	files, err := c.glob(pattern)
+	if err != nil {
+		return nil
+	}
+
+	var conflicts []DelimiterConflict
+	for _, file := range files {
+		src, err := c.readFile(file)
+		if err != nil {
+			continue
+		}
+		conflicts = append(conflicts, findDelimiterConflicts(file, src, c.Delimiters[0], c.Delimiters[1])...)
+	}
+	return conflicts
+}
+
+// findDelimiterConflicts flags left delimiters that are never closed or
+// whose content cannot start an action, such as the "[[" of a nested array
+// literal in documentation when the delimiters are "[[" and "]]".
+func findDelimiterConflicts(file string, src []byte, left, right string) []DelimiterConflict {
+	var conflicts []DelimiterConflict
+
+	for offset := 0; ; {
+		i := bytes.Index(src[offset:], []byte(left))
+		if i < 0 {
+			break
+		}
+		start := offset + i
+		offset = start + len(left)
+
+		reason := ""
+		end := bytes.Index(src[offset:], []byte(right))
+		if end < 0 {
+			reason = "unclosed delimiter " + left
+		} else {
+			action := string(src[offset : offset+end])
+			if !looksLikeAction(action) {
+				reason = "delimiter " + left + " does not start an action"
+			}
+			if reason == "" {
+				offset += end + len(right)
+			}
+		}
+
+		if reason != "" {
+			conflicts = append(conflicts, DelimiterConflict{
+				File:   file,
+				Line:   bytes.Count(src[:start], []byte("\n")) + 1,
+				Text:   lineAt(src, start),
+				Reason: reason,
+			})
+		}
+	}
+
+	return conflicts
+}
+
+// looksLikeAction reports whether s could be the body of a template action
+func looksLikeAction(s string) bool {
+	s = strings.TrimPrefix(s, "- ")
+	s = strings.TrimSuffix(s, " -")
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return false
+	}
+
+	switch r := s[0]; {
+	case r == '.' || r == '$' || r == '(' || r == '"' || r == '`' || r == '\'' || r == '_':
+		return true
+	case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+		return true
+	case strings.HasPrefix(s, "/*"):
+		return true
+	case r >= '0' && r <= '9' || r == '-' || r == '+':
+		// A bare number is a valid action but rarely intended; treat it as
+		// a conflict only when the rest is not numeric either.
+		return strings.Trim(s, "0123456789.-+eExXabcdefABCDEF_i") == ""
+	}
+	return false
+}
+
+// lineAt returns the trimmed source line containing offset
+func lineAt(src []byte, offset int) string {
+	start := bytes.LastIndexByte(src[:offset], '\n') + 1
+	line, _, _ := bytes.Cut(src[start:], []byte("\n"))
+	return strings.TrimSpace(string(line))
+}
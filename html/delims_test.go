@@ -0,0 +1,34 @@
+package html
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindDelimiterConflicts(t *testing.T) {
+	src := []byte("[[.Title]]\nvar grid = [[1, 2], [3]]\n[[/* note */]] [[- $x := 1 -]]\n[[ end")
+	got := findDelimiterConflicts("page.html", src, "[[", "]]")
+	if len(got) != 2 {
+		t.Fatalf("got %v, want 2 conflicts", got)
+	}
+	if got[0].Line != 2 || got[0].Text != "var grid = [[1, 2], [3]]" || !strings.Contains(got[0].Reason, "does not start an action") {
+		t.Errorf("first conflict %+v", got[0])
+	}
+	if got[1].Line != 4 || got[1].Reason != "unclosed delimiter [[" {
+		t.Errorf("second conflict %+v", got[1])
+	}
+	if s := got[1].String(); s != "page.html:4: unclosed delimiter [[: [[ end" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestDelimiterConflictInParseError(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"page.html": "<h1>[[.Title]]</h1>\n<script>var grid = [[1, 2], [3, 4]];</script>",
+	})
+	_, err := Sparkle("*.html", WithTemplateDir(dir), WithDelimiters("[[", "]]")).CreateEngine()
+	if err == nil || !strings.Contains(err.Error(), "possible delimiter conflict at") || !strings.Contains(err.Error(), "page.html:2") {
+		t.Fatalf("got %v, want the conflict located", err)
+	}
+}
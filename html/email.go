@@ -0,0 +1,55 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// RenderEmail renders an email body as Render does, with its subject from
+// the file's {{define "subject"}} block as plain text on one line. The data
+// is prepared once for both, so BeforeRender runs once per email.
+func (h *HTMLTemplate) RenderEmail(name string, data any) (subject string, body []byte, err error) {
+	start := time.Now()
+	if h.config.Development {
+		if err := h.reloadIfNeeded(); err != nil {
+			return "", nil, fmt.Errorf("failed to reload templates: %w", err)
+		}
+	}
+
+	name = h.resolve(name)
+	if err := h.validateTemplate(name); err != nil {
+		return "", nil, err
+	}
+	if data, err = h.config.transform(name, data); err != nil {
+		return "", nil, err
+	}
+	if data, err = h.config.prepare(name, data); err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := h.renderPrepared(context.Background(), start, &buf, name, data); err != nil {
+		return "", nil, err
+	}
+
+	h.mu.RLock()
+	tree := h.subjects[name]
+	h.mu.RUnlock()
+	if tree == nil {
+		return "", buf.Bytes(), nil
+	}
+
+	t, err := template.New("subject").Funcs(h.funcMap()).AddParseTree("subject", tree.Copy())
+	if err != nil {
+		return "", nil, err
+	}
+	var s strings.Builder
+	if err := t.Execute(&s, data); err != nil {
+		return "", nil, err
+	}
+	return strings.Join(strings.Fields(s.String()), " "), buf.Bytes(), nil
+}
@@ -0,0 +1,51 @@
+package html
+
+import "testing"
+
+func TestRenderEmail(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"welcome.html": "{{define \"subject\"}}\n  Welcome, {{.Name}}!\n  Your order\n{{end}}<p>Hi {{.Name}}</p>",
+		"reset.html":   `{{define "subject"}}Reset for {{.Name}}{{end}}<p>Reset</p>`,
+		"plain.html":   `<p>{{.Name}}</p>`,
+	})
+	data := map[string]string{"Name": "Tom & Jerry"}
+
+	tests := []struct {
+		name, subject, body string
+	}{
+		{"welcome.html", "Welcome, Tom & Jerry! Your order", "<p>Hi Tom &amp; Jerry</p>"},
+		{"reset.html", "Reset for Tom & Jerry", "<p>Reset</p>"},
+		{"plain.html", "", "<p>Tom &amp; Jerry</p>"},
+	}
+	for _, tt := range tests {
+		subject, body, err := h.RenderEmail(tt.name, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if subject != tt.subject || string(body) != tt.body {
+			t.Errorf("%s: got %q, %q", tt.name, subject, body)
+		}
+	}
+
+	if _, _, err := h.RenderEmail("missing.html", nil); err == nil {
+		t.Fatal("missing template: want an error")
+	}
+}
+
+func TestRenderEmailPreparesOnce(t *testing.T) {
+	calls := 0
+	h := newTestEngine(t, map[string]string{
+		"welcome.html": `{{define "subject"}}Hi {{.N}}{{end}}<p>{{.N}}</p>`,
+	}, WithBeforeRender(func(name string, data any) (any, error) {
+		calls++
+		return map[string]any{"N": calls}, nil
+	}))
+
+	subject, body, err := h.RenderEmail("welcome.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || subject != "Hi 1" || string(body) != "<p>1</p>" {
+		t.Fatalf("%d calls, got %q, %q", calls, subject, body)
+	}
+}
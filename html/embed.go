@@ -0,0 +1,41 @@
+package html
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"slices"
+	"strings"
+)
+
+// embed renders name inside its layout, the view's by default or none
+// for "", sharing the render's state so recursion is tracked.
+func (rs *renderState) embed(name string, data any, layout ...string) (template.HTML, error) {
+	name = rs.relative(name)
+	if slices.Contains(rs.embeds, name) {
+		return "", fmt.Errorf("embed %s: recursive embed via %s", name, strings.Join(rs.embeds, " -> "))
+	}
+	if err := rs.enter("embed", name); err != nil {
+		return "", err
+	}
+	defer rs.leave()
+
+	l := rs.h.layoutFor(name)
+	if len(layout) > 0 {
+		l = layout[0]
+	}
+
+	data, err := rs.h.config.transform(name, data)
+	if err != nil {
+		return "", err
+	}
+
+	rs.embeds = append(rs.embeds, name)
+	defer func() { rs.embeds = rs.embeds[:len(rs.embeds)-1] }()
+
+	var buf bytes.Buffer
+	if err := rs.h.renderLayout(&buf, l, name, data, rs); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
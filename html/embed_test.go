@@ -0,0 +1,26 @@
+package html
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEmbed(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":         `<page>{{embed "card.html" .}}{{embed "card.html" "bare" ""}}</page>`,
+		"card.html":         `card {{.}}`,
+		"loop.html":         `{{embed "loop.html" .}}`,
+		"layouts/base.html": `<base>{{template "content" .}}</base>`,
+	}, WithLayoutDir("layouts"), WithDefaultLayout("base"))
+
+	if got := renderString(t, h, "page.html", "x"); got != "<page><base>card x</base>card bare</page>" {
+		t.Fatalf("got %q", got)
+	}
+
+	var buf bytes.Buffer
+	err := h.Render(&buf, "loop.html", nil)
+	if err == nil || !strings.Contains(err.Error(), "recursive embed via loop.html") {
+		t.Fatalf("got %v, want the recursion reported", err)
+	}
+}
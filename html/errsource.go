@@ -0,0 +1,109 @@
+package html
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sourceContextLines is how many lines either side of the failing line a
+// SourceError shows
+const sourceContextLines = 2
+
+// errLocation matches the template:line position text/template and
+// html/template put in parse and execution errors. The name may hold
+// colons itself, as prefixed names do, so it ends at the first ":line:".
+var errLocation = regexp.MustCompile(`template: ?(\S+?):(\d+):`)
+
+// SourceError is a render error annotated with the template source around
+// the line that failed. Only development mode produces it.
+type SourceError struct {
+	Template string
+	Line     int
+	Source   string
+	Err      error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%v\n\n%s:%d:\n%s", e.Err, e.Template, e.Line, e.Source)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// withSource wraps err in a SourceError when development mode is on and
+// the failing template's file can be found. Other errors pass through.
+func (h *HTMLTemplate) withSource(err error) error {
+	if err == nil || !h.config.Development {
+		return err
+	}
+
+	m := errLocation.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	line, _ := strconv.Atoi(m[2])
+	src, ok := h.config.templateSource(m[1])
+	if !ok {
+		return err
+	}
+
+	lines := strings.Split(src, "\n")
+	if line < 1 || line > len(lines) {
+		return err
+	}
+	return &SourceError{Template: m[1], Line: line, Source: sourceExcerpt(lines, line), Err: err}
+}
+
+// templateSource reads the file a template named name was parsed from,
+// looking first at the path relative to the template directory and then
+// for a file of that base name anywhere below it
+func (c *Config) templateSource(name string) (string, bool) {
+	if src, err := c.readFile(filepath.Join(c.TemplateDir, name)); err == nil {
+		return string(src), true
+	}
+
+	var found string
+	find := func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() != filepath.Base(name) {
+			return nil
+		}
+		found = path
+		return fs.SkipAll
+	}
+	if fsys := c.fsys(); fsys != nil {
+		fs.WalkDir(fsys, fsPath(c.TemplateDir), find)
+	} else {
+		filepath.WalkDir(c.TemplateDir, find)
+	}
+	if found == "" {
+		return "", false
+	}
+
+	src, err := c.readFile(found)
+	if err != nil {
+		return "", false
+	}
+	return string(src), true
+}
+
+// sourceExcerpt numbers the lines around line, marking it with ">"
+func sourceExcerpt(lines []string, line int) string {
+	first := max(line-sourceContextLines, 1)
+	last := min(line+sourceContextLines, len(lines))
+	width := len(strconv.Itoa(last))
+
+	var b strings.Builder
+	for n := first; n <= last; n++ {
+		mark := " "
+		if n == line {
+			mark = ">"
+		}
+		fmt.Fprintf(&b, "%s %*d | %s\n", mark, width, n, lines[n-1])
+	}
+	return b.String()
+}
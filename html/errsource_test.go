@@ -0,0 +1,57 @@
+package html
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrLocation(t *testing.T) {
+	tests := []struct {
+		msg, name, line string
+	}{
+		{`template: page.html:3: unexpected "}" in operand`, "page.html", "3"},
+		{`template: page.html:3:14: executing "page.html" at <.X>: nil pointer`, "page.html", "3"},
+		{`html/template:page.html:7:2: no such template "x"`, "page.html", "7"},
+		{`template: admin:page.html:12:5: executing "admin:page.html" at <.X>`, "admin:page.html", "12"},
+		{`template: admin/users/list.html:1:1: boom`, "admin/users/list.html", "1"},
+	}
+	for _, tt := range tests {
+		m := errLocation.FindStringSubmatch(tt.msg)
+		if m == nil || m[1] != tt.name || m[2] != tt.line {
+			t.Errorf("%q: got %q, want %s line %s", tt.msg, m, tt.name, tt.line)
+		}
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"v2:page.html": "<p>\n{{.Name.Missing}}\n</p>",
+	}, WithDevelopment(true))
+
+	var buf bytes.Buffer
+	err := h.Render(&buf, "v2:page.html", map[string]any{"Name": "x"})
+	var serr *SourceError
+	if !errors.As(err, &serr) {
+		t.Fatalf("got %v, want a SourceError", err)
+	}
+	if serr.Template != "v2:page.html" || serr.Line != 2 {
+		t.Fatalf("located at %s:%d", serr.Template, serr.Line)
+	}
+	if !strings.Contains(serr.Source, "> 2 | {{.Name.Missing}}") {
+		t.Fatalf("source excerpt %q", serr.Source)
+	}
+}
+
+func TestSourceErrorProduction(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": "{{.Name.Missing}}",
+	})
+	var buf bytes.Buffer
+	err := h.Render(&buf, "page.html", map[string]any{"Name": "x"})
+	var serr *SourceError
+	if err == nil || errors.As(err, &serr) {
+		t.Fatalf("got %v, want a plain error outside development", err)
+	}
+}
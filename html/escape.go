@@ -0,0 +1,100 @@
+package html
+
+import (
+	"encoding/json"
+	"fmt"
+	stdhtml "html"
+	"html/template"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// jsonAttr returns the attribute name="value" with v encoded as JSON and
+// escaped for a double-quoted attribute, for use as <div {{jsonAttr
+// "data-props" .Props}}>. encoding/json already escapes <, >, & and the
+// U+2028/U+2029 line separators, so the value stays inert even if a script
+// later copies it into a <script> block; the HTML escaping on top protects
+// the quotes delimiting the attribute.
+func jsonAttr(name string, v any) (template.HTMLAttr, error) {
+	if !validAttrName(name) {
+		return "", fmt.Errorf("jsonAttr: invalid attribute name %q", name)
+	}
+	if strings.HasPrefix(strings.ToLower(name), "on") {
+		return "", fmt.Errorf("jsonAttr: event handler attribute %q not allowed", name)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("jsonAttr: %w", err)
+	}
+	return template.HTMLAttr(name + `="` + stdhtml.EscapeString(string(b)) + `"`), nil
+}
+
+// validAttrName reports whether name is a plain attribute name made of
+// ASCII letters, digits, '-', '_' and ':'
+func validAttrName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// safeAttrs are the attributes safeAttr accepts by default: their values
+// are plain text to the browser, never a URL, script or style
+var safeAttrs = []string{
+	"abbr", "alt", "autocomplete", "checked", "class", "cols", "colspan",
+	"dir", "disabled", "download", "for", "headers", "height", "hidden",
+	"id", "inputmode", "label", "lang", "max", "maxlength", "min",
+	"minlength", "multiple", "name", "placeholder", "readonly", "rel",
+	"required", "role", "rows", "rowspan", "scope", "selected", "size",
+	"span", "step", "tabindex", "target", "title", "translate", "type",
+	"value", "width", "wrap",
+}
+
+// urlAttrs are attributes whose value the browser loads or navigates to
+var urlAttrs = []string{"action", "cite", "formaction", "href", "poster", "src"}
+
+// safeAttr returns name="value" for attribute names from data. Names must
+// be allow-listed, aria-* or data-*, and never on*; URL values must be
+// http, https or mailto.
+func (c *Config) safeAttr(name, value string) (template.HTMLAttr, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case !validAttrName(name):
+		return "", fmt.Errorf("safeAttr: invalid attribute name %q", name)
+	case strings.HasPrefix(lower, "on"):
+		return "", fmt.Errorf("safeAttr: event handler attribute %q not allowed", name)
+	case slices.Contains(c.SafeAttrs, lower):
+	case slices.Contains(safeAttrs, lower), strings.HasPrefix(lower, "aria-"), strings.HasPrefix(lower, "data-"):
+	default:
+		return "", fmt.Errorf("safeAttr: attribute %q not allowed", name)
+	}
+
+	if slices.Contains(urlAttrs, lower) && !safeURL(value) {
+		return "", fmt.Errorf("safeAttr: unsafe URL in %s", name)
+	}
+	return template.HTMLAttr(name + `="` + stdhtml.EscapeString(value) + `"`), nil
+}
+
+// safeURL reports whether u is relative or uses the http, https or mailto
+// scheme
+func safeURL(u string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(u))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package html
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONAttr(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `<div {{jsonAttr "data-props" .}}></div>`,
+	})
+	got := renderString(t, h, "page.html", map[string]any{"q": `"</script>&'`})
+	want := `<div data-props="{&#34;q&#34;:&#34;\&#34;\u003c/script\u003e\u0026&#39;&#34;}"></div>`
+	if got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+
+	for _, name := range []string{"onclick", "OnLoad", "data props", `x"y`, ""} {
+		if _, err := jsonAttr(name, 1); err == nil {
+			t.Errorf("jsonAttr(%q): want an error", name)
+		}
+	}
+	if _, err := jsonAttr("data-x", func() {}); err == nil || !strings.Contains(err.Error(), "jsonAttr") {
+		t.Errorf("unmarshalable value got %v", err)
+	}
+}
+
+func TestSafeAttr(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"attr.html": `<a {{safeAttr .Name .Value}}>x</a>`,
+	}, WithSafeAttrs("HREF", "onclick"))
+
+	tests := []struct {
+		name, value, want string
+	}{
+		{"title", `"><script>alert(1)</script>`, `<a title="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;">x</a>`},
+		{"data-id", "7", `<a data-id="7">x</a>`},
+		{"aria-label", "Close", `<a aria-label="Close">x</a>`},
+		{"href", "https://example.com/?a=1&b=2", `<a href="https://example.com/?a=1&amp;b=2">x</a>`},
+		{"href", "/relative", `<a href="/relative">x</a>`},
+	}
+	for _, tt := range tests {
+		got := renderString(t, h, "attr.html", map[string]string{"Name": tt.name, "Value": tt.value})
+		if got != tt.want {
+			t.Errorf("%s=%q: got %q, want %q", tt.name, tt.value, got, tt.want)
+		}
+	}
+
+	rejected := []struct {
+		name, value, want string
+	}{
+		{"onclick", "alert(1)", "event handler"},
+		{"ONLOAD", "alert(1)", "event handler"},
+		{"style", "color:red", `attribute "style" not allowed`},
+		{"src", "/x.png", `attribute "src" not allowed`},
+		{`x" onmouseover="alert(1)`, "", "invalid attribute name"},
+		{"href", "javascript:alert(1)", "unsafe URL in href"},
+		{"href", " JavaScript:alert(1)", "unsafe URL in href"},
+	}
+	for _, tt := range rejected {
+		err := h.Render(&strings.Builder{}, "attr.html", map[string]string{"Name": tt.name, "Value": tt.value})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s=%q: got %v, want %q", tt.name, tt.value, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package html
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"net/http"
+)
+
+// RenderEarlyFlush renders like RenderWithLayout, but flushes the layout
+// up to its content block first so the browser can fetch <head> assets
+// early. Keep the content block in plain element content.
+func (h *HTMLTemplate) RenderEarlyFlush(w io.Writer, renderData *RenderData) error {
+	flusher, ok := w.(http.Flusher)
+	layout := renderData.Layout
+	if layout == "" {
+		layout = h.layoutFor(renderData.View)
+	}
+	if !ok || layout == "" {
+		return h.RenderWithLayout(w, renderData)
+	}
+	layout = h.resolve(layout)
+	view := h.resolve(renderData.View)
+
+	data, err := h.config.transform(view, renderData.Data)
+	if err != nil {
+		return err
+	}
+	if data, err = h.config.transform(layout, data); err != nil {
+		return err
+	}
+	if data, err = h.config.prepare(view, data); err != nil {
+		return err
+	}
+
+	rs := &renderState{h: h, w: w, name: layout, stream: true}
+	if err := rs.preload(context.Background(), layout, view); err != nil {
+		return err
+	}
+
+	tpl, _, err := h.wireLayout(layout, view)
+	if err != nil {
+		return err
+	}
+	tpl.Funcs(rs.funcs())
+	if err := h.flushAroundContent(tpl, flusher); err != nil {
+		return err
+	}
+	rs.t = tpl
+
+	if err := tpl.ExecuteTemplate(w, layout, data); err != nil {
+		return h.withSource(rs.result(err))
+	}
+	if err := rs.flushLazy(); err != nil {
+		return rs.result(err)
+	}
+	flusher.Flush()
+	return rs.result(nil)
+}
+
+// flushAroundContent rewires the content block of tpl to flush f before
+// and after it renders
+func (h *HTMLTemplate) flushAroundContent(tpl *template.Template, f http.Flusher) error {
+	content := tpl.Lookup("content")
+	if content == nil || content.Tree == nil {
+		return nil
+	}
+	if _, err := tpl.AddParseTree("content body", content.Tree.Copy()); err != nil {
+		return err
+	}
+
+	tpl.Funcs(template.FuncMap{
+		"flushContent": func() string {
+			f.Flush()
+			return ""
+		},
+	})
+	l, r := h.config.Delimiters[0], h.config.Delimiters[1]
+	src := l + `flushContent` + r + l + `template "content body" .` + r + l + `flushContent` + r
+	_, err := tpl.New("content").Parse(src)
+	return err
+}
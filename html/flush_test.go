@@ -0,0 +1,36 @@
+package html
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestRenderEarlyFlush(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":         `<p>{{.}}</p>`,
+		"layouts/base.html": `<head></head><body>{{template "content" .}}<footer></footer></body>`,
+	}, WithLayoutDir("layouts"), WithDefaultLayout("base"))
+
+	var w flushRecorder
+	if err := h.RenderEarlyFlush(&w, &RenderData{View: "page.html", Data: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"<head></head><body>",
+		"<head></head><body><p>hi</p>",
+		"<head></head><body><p>hi</p><footer></footer></body>",
+	}
+	if !reflect.DeepEqual(w.flushed, want) {
+		t.Fatalf("flushed %q, want %q", w.flushed, want)
+	}
+
+	// A writer that can't flush gets a plain RenderWithLayout
+	var buf bytes.Buffer
+	if err := h.RenderEarlyFlush(&buf, &RenderData{View: "page.html", Data: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want[2] {
+		t.Fatalf("got %q", buf.String())
+	}
+}
@@ -0,0 +1,88 @@
+package html
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Fork returns an independent copy of the engine for tests that need to
+// change it, say with UpdateFuncs or a different language, without touching
+// the original. The fork gets its own clone of the parsed templates, its
+// own copy of the config and its own i18n state. Templates are not parsed
+// again, though the files are read to locate their content blocks.
+func (h *HTMLTemplate) Fork() (*HTMLTemplate, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	t, err := h.base.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	fork := &HTMLTemplate{
+		config:   h.config.clone(),
+		pattern:  h.pattern,
+		layouts:  slices.Clone(h.layouts),
+		lastLoad: h.lastLoad,
+	}
+	if err := fork.swap(t.Funcs(fork.config.mergeFuncs())); err != nil {
+		return nil, err
+	}
+	return fork, nil
+}
+
+// clone copies the config for Fork. Maps and slices are copied so either
+// side can change them; the functions and values they hold are shared.
+func (c *Config) clone() *Config {
+	cc := *c
+	cc.Funcs = maps.Clone(c.Funcs)
+	cc.Delimiters = slices.Clone(c.Delimiters)
+	cc.TemplateFS = slices.Clone(c.TemplateFS)
+	cc.I18n = c.I18n.clone()
+	cc.ContentTypes = maps.Clone(c.ContentTypes)
+	cc.Loaders = maps.Clone(c.Loaders)
+	cc.Globals = maps.Clone(c.Globals)
+	cc.DataTransforms = maps.Clone(c.DataTransforms)
+	cc.Unescaped = slices.Clone(c.Unescaped)
+	cc.ActiveTags = slices.Clone(c.ActiveTags)
+	cc.Aliases = maps.Clone(c.Aliases)
+	cc.NoMemo = slices.Clone(c.NoMemo)
+	cc.LayoutRules = slices.Clone(c.LayoutRules)
+	cc.OutputTransforms = slices.Clone(c.OutputTransforms)
+	cc.SafeAttrs = slices.Clone(c.SafeAttrs)
+	cc.DeprecatedFuncs = maps.Clone(c.DeprecatedFuncs)
+	cc.WatchIgnore = slices.Clone(c.WatchIgnore)
+	cc.PathCandidates = slices.Clone(c.PathCandidates)
+	cc.assetHashes = &sync.Map{}
+	cc.profiles = maps.Clone(c.profiles)
+	cc.inline = maps.Clone(c.inline)
+	return &cc
+}
+
+// clone copies the i18n config, translations included, with the current
+// language carried over
+func (i *I18nConfig) clone() *I18nConfig {
+	if i == nil {
+		return nil
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	translations := make(map[string]map[string]string, len(i.Translations))
+	for lang, messages := range i.Translations {
+		translations[lang] = maps.Clone(messages)
+	}
+	return &I18nConfig{
+		DefaultLang:   i.DefaultLang,
+		Translations:  translations,
+		NativeNames:   maps.Clone(i.NativeNames),
+		Translator:    i.Translator,
+		LocaleFormats: maps.Clone(i.LocaleFormats),
+		Dir:           i.Dir,
+		currentLang:   i.currentLang,
+		dirLangs:      maps.Clone(i.dirLangs),
+		shadowed:      maps.Clone(i.shadowed),
+	}
+}
@@ -0,0 +1,62 @@
+package html
+
+import (
+	"html/template"
+	"reflect"
+	"testing"
+)
+
+func TestFork(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{flag}} {{t "hello"}}`,
+	}, WithFuncs(template.FuncMap{"flag": func() string { return "off" }}), WithI18n("en", testTranslations))
+
+	fork, err := h.Fork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fork.UpdateFuncs(template.FuncMap{"flag": func() string { return "on" }}); err != nil {
+		t.Fatal(err)
+	}
+	fork.config.I18n.SetLanguage("de")
+
+	if got := renderString(t, fork, "page.html", nil); got != "on Hallo" {
+		t.Fatalf("fork got %q", got)
+	}
+	if got := renderString(t, h, "page.html", nil); got != "off Hello" {
+		t.Fatalf("original got %q, want it untouched", got)
+	}
+}
+
+func TestConfigCloneCopiesMapsAndSlices(t *testing.T) {
+	c := Sparkle("*.html",
+		WithGlobals(map[string]any{"a": 1}),
+		WithActiveTags([]string{"beta"}),
+		WithAlias("old", "new"),
+		WithNoMemo("card"),
+		WithSafeAttrs("data-x"),
+		WithWatchIgnore("*.tmp"),
+		WithPathResolution("%s.html"),
+		WithDeprecatedFuncs(map[string]string{"old": "use new"}),
+		WithContentType("feed", "application/atom+xml"),
+	).(*html).config
+	cc := c.clone()
+
+	cv, ccv := reflect.ValueOf(c).Elem(), reflect.ValueOf(cc).Elem()
+	for i := range cv.NumField() {
+		f := cv.Type().Field(i)
+		a, b := cv.Field(i), ccv.Field(i)
+		switch f.Type.Kind() {
+		case reflect.Map, reflect.Slice:
+			if !a.IsNil() && a.Len() > 0 && a.UnsafePointer() == b.UnsafePointer() {
+				t.Errorf("%s is shared between the config and its clone", f.Name)
+			}
+		}
+	}
+	if cc.assetHashes == c.assetHashes {
+		t.Error("asset hashes are shared")
+	}
+	if !reflect.DeepEqual(cc.Globals, c.Globals) || cc.Charset != c.Charset || cc.MaxDepth != c.MaxDepth {
+		t.Error("clone differs from the config")
+	}
+}
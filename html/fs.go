@@ -0,0 +1,154 @@
+package html
+
+import (
+	"errors"
+	"html/template"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// layeredFS stacks file systems so that a file in a later layer overrides
+// the file of the same path in an earlier one. Directories list the union
+// of their entries in every layer.
+type layeredFS []fs.FS
+
+// Open opens name from the last layer that has it. Directories list the
+// merged entries of every layer.
+func (l layeredFS) Open(name string) (fs.File, error) {
+	for _, fsys := range slices.Backward(l) {
+		f, err := fsys.Open(name)
+		if err == nil {
+			return l.mergeDir(name, f)
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir merges the entries of name in every layer, sorted by name, with
+// later layers winning
+func (l layeredFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries := map[string]fs.DirEntry{}
+	found := false
+	for _, fsys := range l {
+		list, err := fs.ReadDir(fsys, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		found = true
+		for _, e := range list {
+			entries[e.Name()] = e
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	names := slices.Sorted(maps.Keys(entries))
+	list := make([]fs.DirEntry, len(names))
+	for i, n := range names {
+		list[i] = entries[n]
+	}
+	return list, nil
+}
+
+// mergeDir wraps f, opened at name, to list the entries of every layer
+// when it is a directory
+func (l layeredFS) mergeDir(name string, f fs.File) (fs.File, error) {
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !info.IsDir() {
+		return f, nil
+	}
+	entries, err := l.ReadDir(name)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &layeredDir{File: f, entries: entries}, nil
+}
+
+// layeredDir is a directory of a layeredFS
+type layeredDir struct {
+	fs.File
+	entries []fs.DirEntry
+}
+
+// ReadDir implements fs.ReadDirFile over the merged entries
+func (d *layeredDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		list := d.entries
+		d.entries = nil
+		return list, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	list := d.entries[:n]
+	d.entries = d.entries[n:]
+	return list, nil
+}
+
+// fsys returns the template file system set with WithTemplateFS, or nil
+// when templates are read from disk
+func (c *Config) fsys() fs.FS {
+	if len(c.TemplateFS) == 0 {
+		return nil
+	}
+	return layeredFS(c.TemplateFS)
+}
+
+// fsPath turns a file path built with filepath into an fs.FS path
+func fsPath(name string) string {
+	return filepath.ToSlash(filepath.Clean(name))
+}
+
+// readFile reads a template file from the template file system or disk
+func (c *Config) readFile(name string) ([]byte, error) {
+	if fsys := c.fsys(); fsys != nil {
+		return fs.ReadFile(fsys, fsPath(name))
+	}
+	return os.ReadFile(name)
+}
+
+// glob returns the template files matching pattern in the template file
+// system or on disk
+func (c *Config) glob(pattern string) ([]string, error) {
+	if fsys := c.fsys(); fsys != nil {
+		return fs.Glob(fsys, fsPath(pattern))
+	}
+	return filepath.Glob(pattern)
+}
+
+// stat describes a template file in the template file system or on disk
+func (c *Config) stat(name string) (fs.FileInfo, error) {
+	if fsys := c.fsys(); fsys != nil {
+		return fs.Stat(fsys, fsPath(name))
+	}
+	return os.Stat(name)
+}
+
+// parseFiles parses files, named as returned by glob, into t
+func (c *Config) parseFiles(t *template.Template, files []string) (*template.Template, error) {
+	if fsys := c.fsys(); fsys != nil {
+		paths := make([]string, len(files))
+		for i, file := range files {
+			paths[i] = fsPath(file)
+		}
+		return t.ParseFS(fsys, paths...)
+	}
+	return t.ParseFiles(files...)
+}
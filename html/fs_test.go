@@ -0,0 +1,40 @@
+package html
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestTemplateFSLayers(t *testing.T) {
+	defaults := fstest.MapFS{
+		"templates/page.html":   {Data: []byte(`<main>{{partial "nav.html"}}{{partial "footer.html"}}</main>`)},
+		"templates/nav.html":    {Data: []byte(`<nav>default</nav>`)},
+		"templates/footer.html": {Data: []byte(`<footer>default</footer>`)},
+	}
+	overrides := fstest.MapFS{
+		"templates/nav.html":   {Data: []byte(`<nav>custom</nav>`)},
+		"templates/extra.html": {Data: []byte(`extra`)},
+	}
+
+	engine, err := Sparkle("*.html", WithTemplateFS(defaults, overrides)).CreateEngine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := engine.(*HTMLTemplate)
+	if got := renderString(t, h, "page.html", nil); got != "<main><nav>custom</nav><footer>default</footer></main>" {
+		t.Fatalf("got %q", got)
+	}
+	if got := renderString(t, h, "extra.html", nil); got != "extra" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestLayeredFS(t *testing.T) {
+	fsys := layeredFS{
+		fstest.MapFS{"a/one.html": {}, "a/two.html": {Data: []byte("old")}},
+		fstest.MapFS{"a/two.html": {Data: []byte("new")}, "b/three.html": {}},
+	}
+	if err := fstest.TestFS(fsys, "a/one.html", "a/two.html", "b/three.html"); err != nil {
+		t.Fatal(err)
+	}
+}
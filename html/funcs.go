@@ -0,0 +1,169 @@
+package html
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// classNames joins the class names whose condition is true, taking a map
+// of name to condition or alternating name and condition arguments:
+//
+//	{{classNames "btn" true "btn-active" .Active}}
+func classNames(args ...any) (string, error) {
+	if len(args) == 1 {
+		m, ok := args[0].(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("classNames: expected map[string]any, got %T", args[0])
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+
+		args = make([]any, 0, 2*len(keys))
+		for _, k := range keys {
+			args = append(args, k, m[k])
+		}
+	}
+
+	if len(args)%2 != 0 {
+		return "", errors.New("classNames: expected name and condition pairs")
+	}
+
+	var classes []string
+	for i := 0; i < len(args); i += 2 {
+		name, ok := args[i].(string)
+		if !ok {
+			return "", fmt.Errorf("classNames: class name must be a string, got %T", args[i])
+		}
+		if truth, _ := template.IsTrue(args[i+1]); truth {
+			classes = append(classes, strings.Fields(name)...)
+		}
+	}
+	return strings.Join(classes, " "), nil
+}
+
+// toFloat64 converts any Go number to float64
+func toFloat64(v any) (float64, error) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), nil
+	case int8:
+		return float64(n), nil
+	case int16:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case uint:
+		return float64(n), nil
+	case uint8:
+		return float64(n), nil
+	case uint16:
+		return float64(n), nil
+	case uint32:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	case uintptr:
+		return float64(n), nil
+	case float32:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	}
+	return 0, fmt.Errorf("expected a number, got %T", v)
+}
+
+// toInt64 returns v as an int64 if it is a Go integer that fits one
+func toInt64(v any) (int64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return int64(u), true
+		}
+	}
+	return 0, false
+}
+
+// mergeData merges maps and structs into one map, later values overriding
+// earlier ones, so a partial can get its parent's data plus extras:
+//
+//	{{partial "card" (mergeData . (dict "compact" true))}}
+//
+// Structs contribute their exported fields, including promoted fields of
+// embedded structs; methods are not carried over. nil values are skipped.
+func mergeData(values ...any) (map[string]any, error) {
+	merged := map[string]any{}
+	for _, v := range values {
+		if err := mergeInto(merged, v); err != nil {
+			return nil, fmt.Errorf("mergeData: %w", err)
+		}
+	}
+	return merged, nil
+}
+
+// mergeInto copies the keys or exported fields of v into m
+func mergeInto(m map[string]any, v any) error {
+	if v == nil {
+		return nil
+	}
+	if src, ok := v.(map[string]any); ok {
+		maps.Copy(m, src)
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("map keys must be strings, got %s", rv.Type().Key())
+		}
+		it := rv.MapRange()
+		for it.Next() {
+			m[it.Key().String()] = it.Value().Interface()
+		}
+	case reflect.Struct:
+		for _, f := range reflect.VisibleFields(rv.Type()) {
+			if !f.IsExported() || f.Anonymous {
+				continue
+			}
+			fv, err := rv.FieldByIndexErr(f.Index)
+			if err != nil {
+				continue // promoted through a nil embedded pointer
+			}
+			m[f.Name] = fv.Interface()
+		}
+	default:
+		return fmt.Errorf("can't merge %T", v)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package html
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassNames(t *testing.T) {
+	tests := []struct {
+		args []any
+		want string
+	}{
+		{[]any{"btn", true, "btn-active", false, "disabled", 1}, "btn disabled"},
+		{[]any{" a b ", "yes", "c", ""}, "a b"},
+		{[]any{map[string]any{"z": true, "a": true, "m": nil}}, "a z"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		got, err := classNames(tt.args...)
+		if err != nil || got != tt.want {
+			t.Errorf("classNames(%v) = %q, %v, want %q", tt.args, got, err, tt.want)
+		}
+	}
+	for _, args := range [][]any{{"btn"}, {1, true}, {map[string]bool{"x": true}}} {
+		if _, err := classNames(args...); err == nil {
+			t.Errorf("classNames(%v): want an error", args)
+		}
+	}
+}
+
+func TestMergeData(t *testing.T) {
+	type Base struct{ ID int }
+	type Page struct {
+		*Base
+		Title  string
+		hidden string
+	}
+	got, err := mergeData(
+		&Page{Base: &Base{ID: 7}, Title: "home", hidden: "x"},
+		map[string]string{"Title": "override"},
+		nil,
+		(*Page)(nil),
+		map[string]any{"compact": true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"ID": 7, "Title": "override", "compact": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// Fields promoted through a nil embedded pointer are skipped
+	if got, err := mergeData(Page{Title: "t"}); err != nil || !reflect.DeepEqual(got, map[string]any{"Title": "t"}) {
+		t.Fatalf("nil embed got %v, %v", got, err)
+	}
+	for _, v := range []any{42, map[int]string{1: "a"}} {
+		if _, err := mergeData(v); err == nil {
+			t.Errorf("mergeData(%v): want an error", v)
+		}
+	}
+}
+
+func TestPartialMergedData(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{partial "card.html" . (dict "Compact" true)}}`,
+		"card.html": `{{.Title}} {{.Compact}}`,
+	})
+	if got := renderString(t, h, "page.html", map[string]any{"Title": "hi", "Compact": false}); got != "hi true" {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -3,3 +3,5 @@ module github.com/fyrte/mofu-templ/html
 go 1.25.0
 
 require github.com/fyrna/mofu v0.0.0-20251025144545-ae50fcc69354
+
+require golang.org/x/net v0.46.0
@@ -0,0 +1,62 @@
+package html
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fyrna/mofu"
+)
+
+// Group routes renders to one of several engines by name prefix, so
+// "admin:dashboard" renders "dashboard" with the engine registered as
+// "admin". Names without a prefix go to the engine registered under "".
+// Engines must be added before the group is used.
+type Group struct {
+	engines map[string]*HTMLTemplate
+}
+
+var _ mofu.TemplateEngine = (*Group)(nil)
+
+// NewGroup creates an empty engine group
+func NewGroup() *Group {
+	return &Group{engines: map[string]*HTMLTemplate{}}
+}
+
+// Add registers engine under prefix, replacing any engine already there
+func (g *Group) Add(prefix string, engine *HTMLTemplate) *Group {
+	g.engines[prefix] = engine
+	return g
+}
+
+// Engine returns the engine registered under prefix
+func (g *Group) Engine(prefix string) (*HTMLTemplate, bool) {
+	engine, ok := g.engines[prefix]
+	return engine, ok
+}
+
+// Render renders name with the engine its prefix selects
+func (g *Group) Render(w io.Writer, name string, data any) error {
+	engine, local, err := g.route(name)
+	if err != nil {
+		return err
+	}
+	return engine.Render(w, local, data)
+}
+
+// route splits name into its engine and the template name local to it
+func (g *Group) route(name string) (*HTMLTemplate, string, error) {
+	prefix, local, ok := strings.Cut(name, ":")
+	if !ok {
+		prefix, local = "", name
+	}
+
+	engine, found := g.engines[prefix]
+	if !found {
+		if prefix == "" {
+			return nil, "", fmt.Errorf("template %s has no engine prefix and no default engine is registered", name)
+		}
+		return nil, "", fmt.Errorf("no engine registered for prefix %q of template %s", prefix, name)
+	}
+	return engine, local, nil
+}
@@ -0,0 +1,34 @@
+package html
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	admin := newTestEngine(t, map[string]string{"dashboard.html": `admin {{.}}`})
+	site := newTestEngine(t, map[string]string{"dashboard.html": `site {{.}}`})
+	g := NewGroup().Add("admin", admin).Add("", site)
+
+	for name, want := range map[string]string{"admin:dashboard.html": "admin x", "dashboard.html": "site x"} {
+		var buf bytes.Buffer
+		if err := g.Render(&buf, name, "x"); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want {
+			t.Errorf("%s got %q, want %q", name, buf.String(), want)
+		}
+	}
+	if e, ok := g.Engine("admin"); !ok || e != admin {
+		t.Error("Engine(admin) is not the admin engine")
+	}
+
+	var buf bytes.Buffer
+	if err := g.Render(&buf, "shop:cart.html", nil); err == nil || !strings.Contains(err.Error(), `no engine registered for prefix "shop"`) {
+		t.Errorf("unknown prefix got %v", err)
+	}
+	if err := NewGroup().Render(&buf, "page.html", nil); err == nil || !strings.Contains(err.Error(), "no default engine") {
+		t.Errorf("no default engine got %v", err)
+	}
+}
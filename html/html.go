@@ -1,14 +1,21 @@
 package html
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"html/template"
 	"io"
+	"io/fs"
 	"log"
 	"maps"
+	"net/http"
+	"path"
 	"path/filepath"
+	"strings"
 	"sync"
+	texttemplate "text/template"
+	"text/template/parse"
 	"time"
 
 	"github.com/fyrna/mofu"
@@ -16,53 +23,156 @@ import (
 
 type HTMLTemplate struct {
 	t        *template.Template
+	base     *template.Template     // never executed, cloned for per-render sets
+	raw      *texttemplate.Template // Unescaped templates, nil if there are none
+	sets     *sync.Pool
 	config   *Config
 	pattern  string
+	layouts  []string
+	stateful bool // some template calls a helper that needs a render set
+	deps     map[string][]string
+	effects  map[string]bool        // templates never memoized, see sideEffects
+	loads    map[string][]string    // template -> data loader keys it uses
+	contents map[string]*parse.Tree // view -> content block defined in its file
+	subjects map[string]*parse.Tree // view -> subject block, see RenderEmail
+	paths    map[string]string      // template name -> file below TemplateDir
+	wired    map[string]*layoutEntry
+	cacheMu  sync.Mutex
 	mu       sync.RWMutex
 	lastLoad time.Time
+	cfgGen   uint64 // bumped when the template set, Funcs or inline sources change
+
+	reloadMu  sync.Mutex
+	reloading *reloadCall
+
+	swrMu sync.Mutex
+	swr   map[string]*swrEntry // RenderSWR key -> cached body
+
+	sizes sync.Map // template -> *sizeCounter, see SizeStats
 }
 
 type Config struct {
-	Funcs         template.FuncMap
-	Delimiters    []string // [left, right]
-	Development   bool
-	AssetVersion  string
-	DefaultLayout string
-	EnableCache   bool
-	TemplateDir   string
-	AssetDir      string
-	I18n          *I18nConfig
+	Funcs            template.FuncMap
+	Delimiters       []string // [left, right]
+	Development      bool
+	AssetVersion     string
+	DefaultLayout    string
+	LayoutDir        string
+	EnableCache      bool
+	TemplateDir      string
+	TemplateFS       []fs.FS // read instead of disk, later layers win
+	AssetDir         string
+	AssetHost        string // absolute asset URLs, e.g. https://cdn.example.com
+	I18n             *I18nConfig
+	StrictFuncs      bool
+	NamePrefix       string
+	RequestData      func(*http.Request) map[string]any
+	ContentTypes     map[string]string // template name -> content type
+	MaxDepth         int
+	NotFound         string // template rendered for unknown names
+	Location         *time.Location
+	Loaders          map[string]func(context.Context) (any, error)
+	LoaderTimeout    time.Duration
+	FragmentDetector func(*http.Request) bool
+	FragmentVary     []string // request headers FragmentDetector reads
+	AutoAssetVersion bool
+	Globals          map[string]any
+	CSP              string // Content-Security-Policy, "{nonce}" is replaced
+	Profile          string // active profile, see WithProfile
+	DataTransforms   map[string]func(any) (any, error)
+	WriteTimeout     time.Duration // zero means no limit
+	AssetURLRewriter func(url string) string
+	RenderHeaders    bool     // development only
+	LaxSections      bool     // failed composite sections are left empty
+	Unescaped        []string // rendered with text/template semantics
+	PanicTemplate    string   // rendered in place of a panicking render
+	AutoTrim         bool
+	ActiveTags       []string // see WithActiveTags
+	OutputBOM        bool
+	Charset          string            // charset of the HTTP Content-Type, default utf-8
+	Aliases          map[string]string // old template name -> new name
+	NoMemo           []string          // partials rendered on every call
+	FuncCheck        bool              // check function references at startup
+	LayoutRules      []LayoutRule      // default layouts by view name
+	Buffered         bool              // failed renders write nothing
+	ErrorPlaceholder string            // replaces failed partials, see RenderIsolated
+	CheckOutput      bool              // development only
+	LayoutByDefault  bool              // Render applies the default layout
+	BeforeRender     func(name string, data any) (any, error)
+	StrictConfig     bool // config problems fail CreateEngine
+	OutputTransforms []OutputTransform
+	Build            BuildInfo
+	BuildComment     bool              // append Build to HTML pages
+	MaxIncludeDepth  int               // zero means no limit
+	SafeAttrs        []string          // extra attributes safeAttr accepts
+	SlowRender       time.Duration     // log renders slower than this
+	DeprecatedFuncs  map[string]string // function name -> replacement advice
+	WatchDebounce    time.Duration     // minimum time between reloads
+	WatchIgnore      []string          // files whose changes don't reload
+	RelativeIncludes bool              // resolve "./" names from the caller
+	CheckAMP         bool              // development only
+	LangCookie       string            // cookie naming the request language
+	SizeStats        bool              // record output sizes, see HTMLTemplate.SizeStats
+	PathCandidates   []string          // resolution order of ResolvePath
+	Overwrite        bool              // AddTemplate may replace templates
+
+	assetHashes *sync.Map // asset name -> content hash
+	profiles    map[string][]Option
+	inline      map[string]string // struct template name -> source
 }
 
+// I18nConfig holds the translations and language of an engine. Nil
+// Translations, nil language bundles and even a nil *I18nConfig are
+// valid: translating then returns the key.
 type I18nConfig struct {
-	DefaultLang  string
-	Translations map[string]map[string]string
+	DefaultLang   string
+	Translations  map[string]map[string]string
+	NativeNames   map[string]string       // language code -> native name
+	Translator    Translator              // replaces Translations when set
+	LocaleFormats map[string]LocaleFormat // language code -> number and date formats
+	Dir           string                  // translation files, see WithI18nDir
 
 	currentLang string
+	dirLangs    map[string]bool              // languages loaded from Dir
+	shadowed    map[string]map[string]string // WithI18n languages Dir replaced
 	mu          sync.RWMutex
 }
 
+// ErrTemplateNotFound is returned by Render after rendering the not-found
+// template in place of an unknown one
+var ErrTemplateNotFound = errors.New("template not found")
+
 // RenderData for layout rendering
 type RenderData struct {
 	Layout string
 	View   string
 	Data   any
+	Lang   string // overrides the current language, see ContextWithLang
 }
 
 // Sparkle creates a new template with optional configuration
 func Sparkle(pattern string, opts ...Option) mofu.TemplateConfig {
 	cfg := &Config{
-		Delimiters:  []string{"{{", "}}"},
-		Funcs:       template.FuncMap{},
-		TemplateDir: "templates",
-		AssetDir:    "assets",
-		EnableCache: true,
+		Delimiters:    []string{"{{", "}}"},
+		Funcs:         template.FuncMap{},
+		TemplateDir:   "templates",
+		AssetDir:      "assets",
+		EnableCache:   true,
+		MaxDepth:      32,
+		LoaderTimeout: 5 * time.Second,
+		FuncCheck:     true,
+		assetHashes:   &sync.Map{},
 	}
 
 	for _, opt := range opts {
 		opt(cfg)
 	}
 
+	// The active profile overrides the base options
+	for _, opt := range cfg.profiles[cfg.Profile] {
+		opt(cfg)
+	}
+
 	return &html{
 		pattern: pattern,
 		config:  cfg,
@@ -75,67 +185,170 @@ type html struct {
 }
 
 func (h *html) CreateEngine() (mofu.TemplateEngine, error) {
-	t, err := h.createTemplate()
+	if p := h.config.Profile; p != "" {
+		if _, ok := h.config.profiles[p]; !ok {
+			return nil, fmt.Errorf("profile %s not defined", p)
+		}
+	}
+
+	if i := h.config.I18n; i != nil && i.Dir != "" {
+		if _, err := i.reloadDir(); err != nil {
+			return nil, err
+		}
+	}
+
+	t, layouts, err := h.createTemplate()
 	if err != nil {
 		return nil, err
 	}
 
 	engine := &HTMLTemplate{
-		t:       t,
-		config:  h.config,
-		pattern: h.pattern,
+		config:   h.config,
+		pattern:  h.pattern,
+		layouts:  layouts,
+		lastLoad: time.Now(),
+	}
+	if err := engine.swap(t); err != nil {
+		return nil, err
 	}
 
 	if err := engine.Validate(); err != nil {
 		return nil, fmt.Errorf("template validation failed: %w", err)
 	}
 
+	if h.config.LayoutDir != "" && h.config.DefaultLayout != "" && !engine.IsLayout(h.config.DefaultLayout) {
+		return nil, fmt.Errorf("default layout %s not found in %s", h.config.DefaultLayout, h.config.LayoutDir)
+	}
+	for _, rule := range h.config.LayoutRules {
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("layout rule %q: %w", rule.Pattern, err)
+		}
+		if h.config.LayoutDir != "" && rule.Layout != "" && !engine.IsLayout(rule.Layout) {
+			return nil, fmt.Errorf("layout %s of rule %q not found in %s", rule.Layout, rule.Pattern, h.config.LayoutDir)
+		}
+	}
+	if err := engine.checkConfig(); err != nil {
+		return nil, err
+	}
+
 	return engine, nil
 }
 
-func (h *html) createTemplate() (*template.Template, error) {
+func (h *html) createTemplate() (*template.Template, []string, error) {
+	return h.config.parse(h.pattern)
+}
+
+// parse builds the template set from the files matching pattern and returns
+// it along with the names of the templates that are layouts
+func (c *Config) parse(pattern string) (*template.Template, []string, error) {
 	t := template.New("")
 
 	// Apply delimiters
-	t = t.Delims(h.config.Delimiters[0], h.config.Delimiters[1])
+	t = t.Delims(c.Delimiters[0], c.Delimiters[1])
 
 	// Merge default funcs with custom funcs
-	funcs := h.mergeFuncs()
+	funcs := c.mergeFuncs()
+	if !c.FuncCheck {
+		maps.Copy(funcs, c.undefinedFuncs(pattern, funcs))
+	}
 	t = t.Funcs(funcs)
 
 	// Parse templates
-	pattern := filepath.Join(h.config.TemplateDir, h.pattern)
-	t, err := t.ParseGlob(pattern)
+	t, err := c.parseGlob(t, filepath.Join(c.TemplateDir, pattern))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return t, nil
+	// Parse layouts
+	t, layouts, err := c.parseLayouts(t)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Parse templates registered from struct tags
+	if err := c.parseInline(t); err != nil {
+		return nil, nil, err
+	}
+
+	// Trim whitespace around actions
+	if c.AutoTrim {
+		autoTrim(t)
+	}
+
+	// Apply name prefix
+	if c.NamePrefix != "" {
+		return c.prefixNames(t, layouts)
+	}
+
+	return t, layouts, nil
 }
 
-func (h *html) mergeFuncs() template.FuncMap {
+// mergeFuncs merges all template functions
+func (c *Config) mergeFuncs() template.FuncMap {
 	funcs := defaultFuncs()
 
 	// Add i18n functions if configured
-	if h.config.I18n != nil {
-		funcs["t"] = h.config.I18n.Translate
-		funcs["setLang"] = h.config.I18n.SetLanguage
-		funcs["currentLang"] = h.config.I18n.CurrentLanguage
+	if c.I18n != nil {
+		funcs["t"] = c.I18n.Translate
+		funcs["tp"] = c.I18n.TranslateNamed
+		funcs["setLang"] = func(lang string) (string, error) {
+			return "", errors.New("setLang is only available while rendering")
+		}
+		funcs["currentLang"] = c.I18n.CurrentLanguage
+		funcs["languageOptions"] = c.I18n.LanguageOptions
 	}
 
+	// Add date and humanizing functions
+	funcs["formatDate"] = func(layout string, t time.Time) string {
+		return formatDate(c.dateLayout("", layout), t, c.location())
+	}
+	funcs["safeAttr"] = c.safeAttr
+	funcs["formatNumber"] = c.formatNumber
+	funcs["formatCurrency"] = c.formatCurrency
+	funcs["inTZ"] = c.inTZ
+	funcs["timeAgo"] = c.timeAgo
+	funcs["humanBytes"] = c.humanBytes
+	funcs["humanDuration"] = c.humanDuration
+
 	// Add asset function
-	if h.config.AssetDir != "" {
+	if c.AssetDir != "" {
 		funcs["asset"] = func(name string) string {
-			return h.config.assetPath(name)
+			return c.assetPath(name)
 		}
+		funcs["srcset"] = c.srcset
+		funcs["image"] = c.image
+		funcs["preload"] = c.preload
 	}
 
 	// Merge with user-provided funcs
-	maps.Copy(funcs, h.config.Funcs)
+	maps.Copy(funcs, c.Funcs)
+
+	if c.PanicTemplate != "" {
+		recoverFuncs(funcs)
+	}
 	return funcs
 }
 
+// assetPath returns the URL of the named asset, on the asset host if one is
+// set, passed through the AssetURLRewriter if one is set
 func (c *Config) assetPath(name string) string {
+	url := c.versionedAsset(name)
+	if c.AssetHost != "" {
+		url = strings.TrimSuffix(c.AssetHost, "/") + "/" + strings.TrimPrefix(filepath.ToSlash(url), "/")
+	}
+	if c.AssetURLRewriter != nil {
+		return c.AssetURLRewriter(url)
+	}
+	return url
+}
+
+// versionedAsset returns the asset's path with its cache-busting version
+func (c *Config) versionedAsset(name string) string {
+	if c.AutoAssetVersion {
+		if v := c.assetHash(name); v != "" {
+			return filepath.Join(c.AssetDir, name) + "?v=" + v
+		}
+	}
 	if c.Development {
 		return filepath.Join(c.AssetDir, name) + "?v=" + time.Now().Format("20060102150405")
 	}
@@ -147,10 +360,12 @@ func (c *Config) assetPath(name string) string {
 
 // Default template functions
 func defaultFuncs() template.FuncMap {
-	return template.FuncMap{
-		"safeHTML": func(s string) template.HTML { return template.HTML(s) },
-		"safeURL":  func(s string) template.URL { return template.URL(s) },
-		"safeJS":   func(s string) template.JS { return template.JS(s) },
+	funcs := template.FuncMap{
+		"safeHTML":   func(s string) template.HTML { return template.HTML(s) },
+		"safeURL":    func(s string) template.URL { return template.URL(s) },
+		"safeJS":     func(s string) template.JS { return template.JS(s) },
+		"jsonAttr":   jsonAttr,
+		"classNames": classNames,
 		"dict": func(values ...any) (map[string]any, error) {
 			if len(values)%2 != 0 {
 				return nil, errors.New("invalid dict call")
@@ -165,14 +380,68 @@ func defaultFuncs() template.FuncMap {
 			}
 			return dict, nil
 		},
-		"partial": func(name string, data any) (template.HTML, error) {
-			// This would be implemented in the Render method
-			return template.HTML(""), nil
+		"mergeData": mergeData,
+		"paginate":  paginate,
+		"partial": func(name string, data ...any) (template.HTML, error) {
+			return "", errors.New("partial is only available while rendering")
+		},
+		"lazy": func(name string, data ...any) (template.HTML, error) {
+			return "", errors.New("lazy is only available while rendering")
+		},
+		"includeIfExists": func(name string, data ...any) (template.HTML, error) {
+			return "", errors.New("includeIfExists is only available while rendering")
+		},
+		"joinTemplates": func(name string, items any, sep any, empty ...any) (template.HTML, error) {
+			return "", errors.New("joinTemplates is only available while rendering")
+		},
+		"streamRange": func(n int, items any) (any, error) {
+			return streamRange(nil, n, items)
+		},
+		"embed": func(name string, data any, layout ...string) (template.HTML, error) {
+			return "", errors.New("embed is only available while rendering")
+		},
+		"load": func(key string) (any, error) {
+			return nil, errors.New("load is only available while rendering")
+		},
+		"once": func(key string, value any, args ...any) (any, error) {
+			return nil, errors.New("once is only available while rendering")
+		},
+		"capture": func(key, name string, data ...any) (string, error) {
+			return "", errors.New("capture is only available while rendering")
+		},
+		"captured": func(key string) (template.HTML, error) {
+			return "", errors.New("captured is only available while rendering")
 		},
 	}
+	maps.Copy(funcs, mathFuncs())
+	return funcs
 }
 
+// Render renders the named template. data may be nil: templates see a nil
+// dot, or a fresh map holding only the globals when WithGlobals is set.
+// With WithLayoutByDefault, views are rendered inside their default layout
+// as by RenderWithLayout.
 func (h *HTMLTemplate) Render(w io.Writer, name string, data any) error {
+	if h.layoutByDefault(name) {
+		return h.RenderWithLayout(w, &RenderData{View: name, Data: data})
+	}
+	return h.RenderContext(context.Background(), w, name, data)
+}
+
+// layoutByDefault reports whether WithLayoutByDefault wraps name in its
+// default layout
+func (h *HTMLTemplate) layoutByDefault(name string) bool {
+	return h.config.LayoutByDefault && !h.IsLayout(name) && h.layoutFor(name) != ""
+}
+
+// RenderNoLayout renders the named template on its own, whether or not
+// WithLayoutByDefault is set
+func (h *HTMLTemplate) RenderNoLayout(w io.Writer, name string, data any) error {
+	return h.RenderContext(context.Background(), w, name, data)
+}
+
+// RenderContext renders a template, passing ctx to the data loaders
+func (h *HTMLTemplate) RenderContext(ctx context.Context, w io.Writer, name string, data any) error {
 	start := time.Now()
 
 	// Development mode: reload templates
@@ -183,44 +452,128 @@ func (h *HTMLTemplate) Render(w io.Writer, name string, data any) error {
 	}
 
 	// Validate template existence
+	name = h.resolve(name)
 	if err := h.validateTemplate(name); err != nil {
+		return h.renderNotFound(ctx, w, name, data, err)
+	}
+
+	// Transform, merge globals and run the before-render hook
+	data, err := h.config.transform(name, data)
+	if err != nil {
+		return err
+	}
+	if data, err = h.config.prepare(name, data); err != nil {
 		return err
 	}
+	return h.renderPrepared(ctx, start, w, name, data)
+}
 
+// renderPrepared is the rest of RenderContext once name is resolved and
+// data is transformed and prepared
+func (h *HTMLTemplate) renderPrepared(ctx context.Context, start time.Time, w io.Writer, name string, data any) error {
 	// Execute the template
-	err := h.t.ExecuteTemplate(w, name, data)
+	w, restore := h.withDeadline(ctx, h.config.withBOM(w))
+	defer restore()
+	w, record := h.countOutput(name, w)
+	w, check := h.checkOutput(name, w)
+	err := h.render(ctx, w, name, data)
+	if err == nil {
+		err = h.config.writeBuildComment(w, name)
+	}
+	if err == nil {
+		check()
+		record()
+	}
 
-	// Log rendering time in development
+	// Log rendering time in development, and slow renders always
+	elapsed := time.Since(start)
 	if h.config.Development {
-		log.Printf("Template %s rendered in %v", name, time.Since(start))
+		log.Printf("Template %s rendered in %v", name, elapsed)
+	}
+	if h.config.SlowRender > 0 && elapsed > h.config.SlowRender {
+		log.Printf("Slow render: template %s took %v (threshold %v)", name, elapsed, h.config.SlowRender)
 	}
 
-	return err
+	return h.withSource(err)
+}
+
+// renderNotFound renders the not-found template in place of name and
+// returns ErrTemplateNotFound. Without one configured it returns err.
+func (h *HTMLTemplate) renderNotFound(ctx context.Context, w io.Writer, name string, data any, err error) error {
+	if h.config.NotFound == "" || h.validateTemplate(h.config.NotFound) != nil {
+		return err
+	}
+
+	if err := h.execute(ctx, w, h.config.NotFound, data); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
 }
 
 // RenderWithLayout for layout-based rendering
 func (h *HTMLTemplate) RenderWithLayout(w io.Writer, renderData *RenderData) error {
-	if renderData.Layout == "" && h.config.DefaultLayout != "" {
-		renderData.Layout = h.config.DefaultLayout
+	if renderData.Layout == "" {
+		renderData.Layout = h.layoutFor(renderData.View)
 	}
 
 	if renderData.Layout == "" {
-		return h.Render(w, renderData.View, renderData.Data)
+		ctx := context.Background()
+		if renderData.Lang != "" {
+			ctx = ContextWithLang(ctx, renderData.Lang)
+		}
+		return h.RenderContext(ctx, w, renderData.View, renderData.Data)
 	}
+	renderData.Layout = h.resolve(renderData.Layout)
+	renderData.View = h.resolve(renderData.View)
 
-	// Create a clone to avoid modifying the original template
-	tpl, err := h.t.Clone()
+	data, err := h.config.transform(renderData.View, renderData.Data)
 	if err != nil {
 		return err
 	}
+	if data, err = h.config.transform(renderData.Layout, data); err != nil {
+		return err
+	}
+	if renderData.Data, err = h.config.prepare(renderData.View, data); err != nil {
+		return err
+	}
 
-	// Define the content block
-	_, err = tpl.New("content").Parse(`{{define "content"}}{{template "` + renderData.View + `" .}}{{end}}`)
+	w, restore := h.withDeadline(context.Background(), h.config.withBOM(w))
+	defer restore()
+	w, record := h.countOutput(renderData.View, w)
+	w, check := h.checkOutput(renderData.View, w)
+
+	err = h.renderOutput(context.Background(), w, renderData.View, func(w io.Writer) error {
+		return h.executeLayout(w, renderData)
+	})
 	if err != nil {
+		return h.withSource(err)
+	}
+	if err := h.config.writeBuildComment(w, renderData.Layout); err != nil {
 		return err
 	}
+	check()
+	record()
+	return nil
+}
+
+// executeLayout renders the view of renderData inside its layout
+func (h *HTMLTemplate) executeLayout(w io.Writer, renderData *RenderData) error {
+	if h.config.EnableCache {
+		set, err := h.acquireLayoutSet(w, renderData.Layout, renderData.View)
+		if err != nil {
+			return err
+		}
+		defer set.release()
+
+		set.rs.lang = renderData.Lang
+		return set.run(context.Background(), renderData.Layout, renderData.Data, renderData.Layout, renderData.View)
+	}
 
-	return tpl.ExecuteTemplate(w, renderData.Layout, renderData.Data)
+	rs := &renderState{h: h, w: w, name: renderData.Layout, lang: renderData.Lang}
+	if err := rs.preload(context.Background(), renderData.Layout, renderData.View); err != nil {
+		return err
+	}
+	return rs.result(h.renderLayout(w, renderData.Layout, renderData.View, renderData.Data, rs))
 }
 
 func (h *HTMLTemplate) Validate() error {
@@ -243,7 +596,17 @@ func (h *HTMLTemplate) Validate() error {
 		}
 	}
 
-	return nil
+	// Check function references, including the content blocks that only
+	// join the set at render time
+	if !h.config.FuncCheck {
+		return nil
+	}
+	funcs := h.config.mergeFuncs()
+	errs := checkFuncs(h.t, funcs)
+	for view, tree := range h.contents {
+		errs = append(errs, checkTreeFuncs(view+" content", tree, funcs)...)
+	}
+	return errors.Join(errs...)
 }
 
 func (h *HTMLTemplate) TemplateNames() []string {
@@ -269,96 +632,234 @@ func (h *HTMLTemplate) validateTemplate(name string) error {
 		return errors.New("template engine not initialized")
 	}
 
-	if h.t.Lookup(name) == nil {
+	if h.t.Lookup(h.config.aliasTarget(name)) == nil {
 		return fmt.Errorf("template %s not found", name)
 	}
 
 	return nil
 }
 
-// mergeFuncs merges all template functions
-func (h *HTMLTemplate) mergeFuncs() template.FuncMap {
-	funcs := defaultFuncs()
+// reloadCall is a reload in progress, shared by every render that asks
+// for a reload while it runs
+type reloadCall struct {
+	done chan struct{}
+	err  error
+}
 
-	// Add i18n functions if configured
-	if h.config.I18n != nil {
-		funcs["t"] = h.config.I18n.Translate
-		funcs["setLang"] = h.config.I18n.SetLanguage
-		funcs["currentLang"] = h.config.I18n.CurrentLanguage
+// reloadIfNeeded reloads templates in development mode. Concurrent calls
+// are coalesced: while a reload runs, other callers wait for it and share
+// its result instead of each reparsing. On error the previously loaded
+// templates stay in place.
+func (h *HTMLTemplate) reloadIfNeeded() error {
+	if !h.reloadDue() {
+		return nil
 	}
 
-	// Add asset function
-	if h.config.AssetDir != "" {
-		funcs["asset"] = func(name string) string {
-			return h.config.assetPath(name)
+	h.reloadMu.Lock()
+	if call := h.reloading; call != nil {
+		h.reloadMu.Unlock()
+		<-call.done
+		return call.err
+	}
+	call := &reloadCall{done: make(chan struct{})}
+	h.reloading = call
+	h.reloadMu.Unlock()
+
+	call.err = h.reload()
+
+	h.reloadMu.Lock()
+	h.reloading = nil
+	h.reloadMu.Unlock()
+	close(call.done)
+
+	return call.err
+}
+
+// reload parses the templates and swaps them in. Parsing happens outside
+// the lock so renders keep using the current set meanwhile. It parses a
+// snapshot of the config, and parses again if UpdateFuncs, AddTemplate or
+// RegisterStructTemplates changed it in the meantime.
+func (h *HTMLTemplate) reload() error {
+	if err := h.reloadTranslations(); err != nil {
+		return err
+	}
+
+	for {
+		h.mu.RLock()
+		c, gen := *h.config, h.cfgGen
+		h.mu.RUnlock()
+
+		t, layouts, err := c.parse(h.pattern)
+		if err != nil {
+			return err
+		}
+
+		h.mu.Lock()
+		if h.cfgGen != gen {
+			h.mu.Unlock()
+			continue
 		}
+		err = h.swap(t)
+		if err == nil {
+			h.layouts = layouts
+			h.lastLoad = time.Now()
+		}
+		h.mu.Unlock()
+		return err
 	}
+}
 
-	// Merge with user-provided funcs
-	maps.Copy(funcs, h.config.Funcs)
-	return funcs
+// funcMap returns the engine's functions, read under the lock UpdateFuncs
+// and AddTemplate publish them with
+func (h *HTMLTemplate) funcMap() template.FuncMap {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.config.mergeFuncs()
 }
 
-// reloadIfNeeded reloads templates in development mode
-func (h *HTMLTemplate) reloadIfNeeded() error {
+// UpdateFuncs adds or replaces template functions without reparsing, for
+// helpers such as feature flags whose behavior changes at runtime. The
+// parsed templates are cloned with the new functions and swapped in; renders
+// in flight finish with the old ones. Only what the functions return
+// changes: templates keep their structure, and a function no template
+// calls yet can only be used after the templates are reparsed.
+func (h *HTMLTemplate) UpdateFuncs(funcs template.FuncMap) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	t := template.New("")
+	merged := maps.Clone(h.config.Funcs)
+	if merged == nil {
+		merged = template.FuncMap{}
+	}
+	maps.Copy(merged, funcs)
 
-	// Apply delimiters
-	t = t.Delims(h.config.Delimiters[0], h.config.Delimiters[1])
+	t, err := h.base.Clone()
+	if err != nil {
+		return err
+	}
 
-	// Apply all functions (including custom ones)
-	funcs := h.mergeFuncs()
-	t = t.Funcs(funcs)
+	old := h.config.Funcs
+	h.config.Funcs = merged
+	t.Funcs(h.config.mergeFuncs())
+	if err := h.swap(t); err != nil {
+		h.config.Funcs = old
+		return err
+	}
+	h.cfgGen++
+	return nil
+}
 
-	// Parse templates with correct pattern
-	pattern := filepath.Join(h.config.TemplateDir, h.pattern)
-	t, err := t.ParseGlob(pattern)
+// swap installs a freshly parsed template set. The caller must hold the
+// write lock (or own the engine exclusively during construction).
+func (h *HTMLTemplate) swap(t *template.Template) error {
+	base, err := t.Clone()
 	if err != nil {
 		return err
 	}
+	raw, err := h.config.rawSet(base)
+	if err != nil {
+		return err
+	}
+	if err := h.config.checkAliases(t); err != nil {
+		return err
+	}
+	deps := templateDeps(t)
+	if err := h.config.checkIncludeDepth(deps); err != nil {
+		return err
+	}
+	h.config.warnDeprecated(t)
 
 	h.t = t
-	h.lastLoad = time.Now()
+	h.base = base
+	h.raw = raw
+	h.sets = &sync.Pool{}
+	h.stateful = usesFuncs(t, statefulFuncs...)
+	h.deps = deps
+	h.effects = sideEffects(t, deps)
+	h.loads = loadKeys(t)
+	h.contents = h.config.fileBlocks(t, h.pattern, "content")
+	h.subjects = h.config.fileBlocks(t, h.pattern, "subject")
+	h.paths = h.config.templatePaths(h.pattern)
+	h.cfgGen++
+
+	h.cacheMu.Lock()
+	h.wired = map[string]*layoutEntry{}
+	h.cacheMu.Unlock()
 	return nil
 }
 
 // I18n methods
 func (i *I18nConfig) Translate(key string, args ...any) string {
+	return i.translateIn("", key, args...)
+}
+
+// translateIn is Translate in lang, or the current language when lang is
+// empty
+func (i *I18nConfig) translateIn(lang, key string, args ...any) string {
+	if i == nil {
+		return key
+	}
 	i.mu.RLock()
 	defer i.mu.RUnlock()
 
-	lang := i.currentLang
 	if lang == "" {
-		lang = i.DefaultLang
+		lang = i.lang()
 	}
-
-	translations, exists := i.Translations[lang]
+	translation, exists := i.translator().Translate(lang, key, args...)
 	if !exists {
 		return key
 	}
+	return translation
+}
 
-	translation, exists := translations[key]
-	if !exists {
-		return key
+// lang returns the current language, defaulting to DefaultLang. The caller
+// must hold the read lock.
+func (i *I18nConfig) lang() string {
+	if i.currentLang == "" {
+		return i.DefaultLang
 	}
+	return i.currentLang
+}
 
-	if len(args) > 0 {
-		return fmt.Sprintf(translation, args...)
-	}
+// TranslateNamed translates key and fills its named placeholders, such as
+// {name} in "Hello {name}", from values, so translators can reorder them
+// freely. Placeholders without a value are left as written. Like
+// Translate, it falls back to the key when there is no translation.
+func (i *I18nConfig) TranslateNamed(key string, values map[string]any) string {
+	return i.translateNamedIn("", key, values)
+}
 
-	return translation
+// translateNamedIn is TranslateNamed in lang, or the current language when
+// lang is empty
+func (i *I18nConfig) translateNamedIn(lang, key string, values map[string]any) string {
+	if i == nil {
+		return interpolate(key, values)
+	}
+	i.mu.RLock()
+	if lang == "" {
+		lang = i.lang()
+	}
+	translation, exists := i.translator().Translate(lang, key)
+	i.mu.RUnlock()
+	if !exists {
+		translation = key
+	}
+	return interpolate(translation, values)
 }
 
 func (i *I18nConfig) SetLanguage(lang string) {
+	if i == nil {
+		return
+	}
 	i.mu.Lock()
 	defer i.mu.Unlock()
 	i.currentLang = lang
 }
 
 func (i *I18nConfig) CurrentLanguage() string {
+	if i == nil {
+		return ""
+	}
 	i.mu.RLock()
 	defer i.mu.RUnlock()
 	return i.currentLang
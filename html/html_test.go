@@ -0,0 +1,143 @@
+package html
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newTestEngine writes files, keyed by slash-separated path, below a
+// temporary template directory and builds an engine over its *.html files
+func newTestEngine(t testing.TB, files map[string]string, opts ...Option) *HTMLTemplate {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, files)
+
+	opts = append([]Option{WithTemplateDir(dir)}, opts...)
+	engine, err := Sparkle("*.html", opts...).CreateEngine()
+	if err != nil {
+		t.Fatalf("CreateEngine: %v", err)
+	}
+	return engine.(*HTMLTemplate)
+}
+
+func writeFiles(t testing.TB, dir string, files map[string]string) {
+	t.Helper()
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// captureLogs redirects the standard logger to a buffer for the rest of
+// the test
+func captureLogs(t testing.TB) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+// renderString renders name with data and fails the test on error
+func renderString(t testing.TB, h *HTMLTemplate, name string, data any) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := h.Render(&buf, name, data); err != nil {
+		t.Fatalf("Render %s: %v", name, err)
+	}
+	return buf.String()
+}
+
+func TestUpdateFuncs(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"flag.html": `{{flag}}`,
+	}, WithFuncs(template.FuncMap{"flag": func() string { return "off" }}))
+
+	if got := renderString(t, h, "flag.html", nil); got != "off" {
+		t.Fatalf("got %q, want off", got)
+	}
+	if err := h.UpdateFuncs(template.FuncMap{"flag": func() string { return "on" }}); err != nil {
+		t.Fatal(err)
+	}
+	if got := renderString(t, h, "flag.html", nil); got != "on" {
+		t.Fatalf("after UpdateFuncs got %q, want on", got)
+	}
+	if err := h.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := renderString(t, h, "flag.html", nil); got != "on" {
+		t.Fatalf("after reload got %q, want on", got)
+	}
+}
+
+func TestUpdateFuncsDuringReload(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"flag.html": `{{flag}}`,
+	}, WithFuncs(template.FuncMap{"flag": func() string { return "0" }}), WithStrictFuncs(true), WithDevelopment(true))
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 20 {
+				if err := h.reload(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		})
+		wg.Go(func() {
+			for range 50 {
+				var buf bytes.Buffer
+				if err := h.Render(&buf, "flag.html", nil); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		})
+	}
+	for i := range 20 {
+		v := fmt.Sprint(i + 1)
+		if err := h.UpdateFuncs(template.FuncMap{"flag": func() string { return v }}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	if got := renderString(t, h, "flag.html", nil); got != "20" {
+		t.Fatalf("got %q, want the last update 20", got)
+	}
+}
+
+func TestNotFoundTemplate(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"404.html": `no {{.}}`,
+	}, WithNotFoundTemplate("404.html"))
+
+	var buf bytes.Buffer
+	err := h.Render(&buf, "missing.html", "page")
+	if !errors.Is(err, ErrTemplateNotFound) || !strings.Contains(err.Error(), "missing.html") {
+		t.Fatalf("got %v, want ErrTemplateNotFound", err)
+	}
+	if buf.String() != "no page" {
+		t.Fatalf("got %q, want the not-found page", buf.String())
+	}
+
+	h = newTestEngine(t, map[string]string{"page.html": ``})
+	buf.Reset()
+	if err := h.Render(&buf, "missing.html", nil); err == nil || errors.Is(err, ErrTemplateNotFound) {
+		t.Fatalf("got %v, want a plain error without a not-found template", err)
+	}
+}
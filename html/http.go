@@ -0,0 +1,264 @@
+package html
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RenderHTTP renders a template as an HTTP response. The Content-Type is
+// taken from WithContentType or the template's extension. Values from the
+// WithRequestData function are merged into data before rendering. With
+// WithCSP set, security headers are sent and the CSP nonce is available to
+// the template as .CSPNonce. With WithLayoutByDefault, views are rendered
+// inside their default layout as by RenderHTTPWithLayout.
+func (h *HTMLTemplate) RenderHTTP(w http.ResponseWriter, r *http.Request, name string, data any) error {
+	if h.layoutByDefault(name) {
+		return h.RenderHTTPWithLayout(w, r, &RenderData{View: name, Data: data})
+	}
+	return h.renderHTTP(w, r, name, data, "")
+}
+
+// renderHTTP is RenderHTTP in lang, or the language of the request when
+// lang is empty
+func (h *HTMLTemplate) renderHTTP(w http.ResponseWriter, r *http.Request, name string, data any, lang string) error {
+	w.Header().Set("Content-Type", h.config.contentType(name))
+	nonce := h.autoSecurityHeaders(w)
+
+	if lang == "" {
+		lang = h.requestLang(w, r)
+	}
+	ctx := r.Context()
+	if lang != "" {
+		ctx = ContextWithLang(ctx, lang)
+	}
+	return h.renderMeasured(w, func(out io.Writer) error {
+		return h.RenderContext(ctx, out, name, h.requestData(r, data, nonce))
+	})
+}
+
+// RenderHTTPWithLayout renders a view inside a layout as an HTTP response.
+// Fragment requests, such as those sent by HTMX, get the view alone; see
+// WithFragmentDetector. Values from the WithRequestData function are
+// merged into the view data.
+func (h *HTMLTemplate) RenderHTTPWithLayout(w http.ResponseWriter, r *http.Request, renderData *RenderData) error {
+	addVary(w.Header(), h.config.fragmentHeaders()...)
+	if h.config.fragmentRequest(r) {
+		return h.renderHTTP(w, r, renderData.View, renderData.Data, renderData.Lang)
+	}
+
+	rd := *renderData
+	if rd.Layout == "" {
+		rd.Layout = h.layoutFor(rd.View)
+	}
+
+	// The outermost template decides what kind of document this is
+	if rd.Layout != "" {
+		w.Header().Set("Content-Type", h.config.contentType(rd.Layout))
+	} else {
+		w.Header().Set("Content-Type", h.config.contentType(rd.View))
+	}
+
+	if rd.Lang == "" {
+		rd.Lang = h.requestLang(w, r)
+	}
+
+	nonce := h.autoSecurityHeaders(w)
+	rd.Data = h.requestData(r, rd.Data, nonce)
+	return h.renderMeasured(w, func(out io.Writer) error {
+		return h.RenderWithLayout(out, &rd)
+	})
+}
+
+// RenderNegotiated answers a request with data marshaled to JSON when its
+// Accept header prefers application/json over HTML, and with the named
+// template rendered by RenderHTTP otherwise, so missing, wildcard and
+// tied Accept headers get HTML.
+func (h *HTMLTemplate) RenderNegotiated(w http.ResponseWriter, r *http.Request, name string, data any) error {
+	addVary(w.Header(), "Accept")
+	if !prefersJSON(r.Header.Get("Accept")) {
+		return h.RenderHTTP(w, r, name, data)
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("render %s as JSON: %w", name, err)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, err = w.Write(append(body, '\n'))
+	return err
+}
+
+// prefersJSON reports whether accept ranks application/json strictly above
+// text/html, by quality and then by specificity of the matching ranges
+func prefersJSON(accept string) bool {
+	jsonQ, jsonSpec := acceptQuality(accept, "application", "json")
+	htmlQ, htmlSpec := acceptQuality(accept, "text", "html")
+	if jsonQ != htmlQ {
+		return jsonQ > htmlQ
+	}
+	return jsonQ > 0 && jsonSpec > htmlSpec
+}
+
+// acceptQuality returns the quality accept gives typ/sub, taken from the
+// most specific range matching it, and that range's specificity: 2 for an
+// exact match, 1 for typ/*, 0 for */*
+func acceptQuality(accept, typ, sub string) (q float64, spec int) {
+	spec = -1
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaRange, params, _ := strings.Cut(part, ";")
+		rangeType, rangeSub, _ := strings.Cut(strings.TrimSpace(mediaRange), "/")
+
+		s := -1
+		switch {
+		case strings.EqualFold(rangeType, typ) && strings.EqualFold(rangeSub, sub):
+			s = 2
+		case strings.EqualFold(rangeType, typ) && rangeSub == "*":
+			s = 1
+		case rangeType == "*" && rangeSub == "*":
+			s = 0
+		}
+		if s <= spec {
+			continue
+		}
+
+		spec, q = s, 1
+		for param := range strings.SplitSeq(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if v, err := strconv.ParseFloat(value, 64); err == nil {
+					q = v
+				}
+			}
+		}
+	}
+	return q, spec
+}
+
+// addVary adds the request header fields to the Vary header of a
+// response, skipping those it already lists
+func addVary(header http.Header, fields ...string) {
+	var listed []string
+	for _, v := range header.Values("Vary") {
+		for field := range strings.SplitSeq(v, ",") {
+			listed = append(listed, strings.ToLower(strings.TrimSpace(field)))
+		}
+	}
+	if slices.Contains(listed, "*") {
+		return
+	}
+	for _, field := range fields {
+		if !slices.Contains(listed, strings.ToLower(field)) {
+			header.Add("Vary", field)
+			listed = append(listed, strings.ToLower(field))
+		}
+	}
+}
+
+// renderMeasured runs render against w. With render headers enabled in
+// development mode the output is buffered so the X-Render-Time,
+// X-Render-Size, X-Render-Mode and Server-Timing headers can be sent
+// ahead of it.
+func (h *HTMLTemplate) renderMeasured(w http.ResponseWriter, render func(io.Writer) error) error {
+	if !h.config.Development || !h.config.RenderHeaders {
+		return render(w)
+	}
+
+	var buf bytes.Buffer
+	start := time.Now()
+	err := render(&buf)
+	elapsed := time.Since(start)
+
+	if err == nil {
+		ms := float64(elapsed.Microseconds()) / 1000
+		w.Header().Set("X-Render-Time", elapsed.String())
+		w.Header().Set("X-Render-Size", strconv.Itoa(buf.Len()))
+		w.Header().Set("X-Render-Mode", string(h.OutputMode()))
+		w.Header().Add("Server-Timing", fmt.Sprintf("render;dur=%.3f", ms))
+	}
+	if _, werr := buf.WriteTo(w); err == nil {
+		err = werr
+	}
+	return err
+}
+
+// requestData merges the values of the request data function and the CSP
+// nonce with the page data, as mergeValues does. Page data wins on
+// conflicts.
+func (h *HTMLTemplate) requestData(r *http.Request, data any, nonce string) any {
+	if h.config.RequestData == nil && nonce == "" {
+		return data
+	}
+
+	base := map[string]any{}
+	if h.config.RequestData != nil {
+		base = h.config.RequestData(r)
+	}
+	if nonce != "" {
+		base = mergeValues(base, map[string]any{"CSPNonce": nonce})
+	}
+	return mergeValues(base, data)
+}
+
+// extContentTypes maps template extensions to the content types they
+// produce. Anything else is served as HTML.
+var extContentTypes = map[string]string{
+	".xml":  "application/xml; charset=utf-8",
+	".rss":  "application/rss+xml; charset=utf-8",
+	".atom": "application/atom+xml; charset=utf-8",
+	".svg":  "image/svg+xml; charset=utf-8",
+	".json": "application/json; charset=utf-8",
+	".txt":  "text/plain; charset=utf-8",
+	".css":  "text/css; charset=utf-8",
+	".js":   "text/javascript; charset=utf-8",
+}
+
+// contentType returns the Content-Type for the named template, with the
+// configured charset
+func (c *Config) contentType(name string) string {
+	if ct, ok := c.ContentTypes[name]; ok {
+		return ct
+	}
+	if ct, ok := extContentTypes[strings.ToLower(filepath.Ext(name))]; ok {
+		return c.withCharset(ct)
+	}
+	return c.withCharset("text/html; charset=utf-8")
+}
+
+// fragmentRequest reports whether r asks for a page fragment rather than a
+// full page
+func (c *Config) fragmentRequest(r *http.Request) bool {
+	if c.FragmentDetector != nil {
+		return c.FragmentDetector(r)
+	}
+	return IsFragmentRequest(r)
+}
+
+// fragmentHeaders returns the request headers fragment detection depends
+// on, for Vary
+func (c *Config) fragmentHeaders() []string {
+	if c.FragmentDetector != nil {
+		if len(c.FragmentVary) > 0 {
+			return c.FragmentVary
+		}
+		return []string{"HX-Request"}
+	}
+	return []string{"HX-Request", "HX-History-Restore-Request", "X-Requested-With"}
+}
+
+// IsFragmentRequest is the default fragment detector. It recognizes HTMX
+// requests (HX-Request) and XMLHttpRequest calls (X-Requested-With), except
+// HTMX history restores, which need the full page.
+func IsFragmentRequest(r *http.Request) bool {
+	if r.Header.Get("HX-Request") == "true" {
+		return r.Header.Get("HX-History-Restore-Request") != "true"
+	}
+	return strings.EqualFold(r.Header.Get("X-Requested-With"), "XMLHttpRequest")
+}
@@ -0,0 +1,262 @@
+package html
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAddVary(t *testing.T) {
+	header := http.Header{}
+	header.Add("Vary", "accept-language")
+	addVary(header, "Accept-Language", "Cookie", "Cookie")
+	if got := header.Values("Vary"); !slices.Equal(got, []string{"accept-language", "Cookie"}) {
+		t.Fatalf("got %q", got)
+	}
+
+	header = http.Header{"Vary": {"*"}}
+	addVary(header, "Cookie")
+	if got := header.Values("Vary"); !slices.Equal(got, []string{"*"}) {
+		t.Fatalf("got %q, want * alone", got)
+	}
+}
+
+func TestRenderHTTPVary(t *testing.T) {
+	files := map[string]string{"page.html": `page`}
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{"no i18n", nil, nil},
+		{"i18n", []Option{WithI18n("en", testTranslations)}, []string{"Accept-Language"}},
+		{"i18n and cookie", []Option{WithI18n("en", testTranslations), WithLangCookie("lang")}, []string{"Accept-Language", "Cookie"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestEngine(t, files, tt.opts...)
+			w := httptest.NewRecorder()
+			if err := h.RenderHTTP(w, httptest.NewRequest("GET", "/", nil), "page.html", nil); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Header().Values("Vary"); !slices.Equal(got, tt.want) {
+				t.Fatalf("Vary %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderHTTPWithLayoutVary(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":         `{{t "hello"}}`,
+		"layouts/base.html": `<main>{{template "content" .}}</main>`,
+	}, WithI18n("en", testTranslations), WithLayoutDir("layouts"), WithDefaultLayout("base"))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Language", "de")
+	w := httptest.NewRecorder()
+	if err := h.RenderHTTPWithLayout(w, r, &RenderData{View: "page.html"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != "<main>Hallo</main>" {
+		t.Fatalf("got %q", got)
+	}
+	if got := w.Header().Values("Vary"); !slices.Equal(got, []string{"HX-Request", "HX-History-Restore-Request", "X-Requested-With", "Accept-Language"}) {
+		t.Fatalf("Vary %q", got)
+	}
+
+	// A language chosen by the caller doesn't depend on the request
+	w = httptest.NewRecorder()
+	if err := h.RenderHTTPWithLayout(w, r, &RenderData{View: "page.html", Lang: "en"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != "<main>Hello</main>" {
+		t.Fatalf("got %q", got)
+	}
+	if got := w.Header().Values("Vary"); !slices.Equal(got, []string{"HX-Request", "HX-History-Restore-Request", "X-Requested-With"}) {
+		t.Fatalf("Vary %q", got)
+	}
+}
+
+func TestRenderHTTPRequestData(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{.Path}} {{.User}}`,
+		"item.html": `{{.Path}} {{.Data}}`,
+	}, WithRequestData(func(r *http.Request) map[string]any {
+		return map[string]any{"Path": r.URL.Path, "User": "guest"}
+	}))
+
+	w := httptest.NewRecorder()
+	if err := h.RenderHTTP(w, httptest.NewRequest("GET", "/home", nil), "page.html", map[string]any{"User": "ann"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != "/home ann" {
+		t.Fatalf("got %q, want page data to win", got)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Fatalf("Content-Type %q", ct)
+	}
+
+	w = httptest.NewRecorder()
+	if err := h.RenderHTTP(w, httptest.NewRequest("GET", "/item", nil), "item.html", 42); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != "/item 42" {
+		t.Fatalf("got %q, want non-map data under .Data", got)
+	}
+
+	w = httptest.NewRecorder()
+	data := struct{ User string }{"bob"}
+	if err := h.RenderHTTP(w, httptest.NewRequest("GET", "/home", nil), "page.html", data); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != "/home bob" {
+		t.Fatalf("got %q, want struct fields merged over request data", got)
+	}
+}
+
+func TestContentType(t *testing.T) {
+	c := Sparkle("*.html", WithContentType("feed.html", "application/atom+xml")).(*html).config
+	tests := map[string]string{
+		"page.html":   "text/html; charset=utf-8",
+		"sitemap.xml": "application/xml; charset=utf-8",
+		"Feed.RSS":    "application/rss+xml; charset=utf-8",
+		"feed.html":   "application/atom+xml",
+		"noext":       "text/html; charset=utf-8",
+	}
+	for name, want := range tests {
+		if got := c.contentType(name); got != want {
+			t.Errorf("contentType(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestRenderHTTPWithLayoutContentType(t *testing.T) {
+	h := newTestEngine(t, layoutFiles, WithLayoutDir("layouts"),
+		WithContentType("page.html", "text/plain"), WithContentType("base", "application/xhtml+xml"))
+
+	w := httptest.NewRecorder()
+	if err := h.RenderHTTPWithLayout(w, httptest.NewRequest("GET", "/", nil), &RenderData{View: "page.html", Layout: "base"}); err != nil {
+		t.Fatal(err)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/xhtml+xml" {
+		t.Fatalf("Content-Type %q, want the layout's", ct)
+	}
+}
+
+func TestIsFragmentRequest(t *testing.T) {
+	tests := []struct {
+		headers map[string]string
+		want    bool
+	}{
+		{nil, false},
+		{map[string]string{"HX-Request": "true"}, true},
+		{map[string]string{"HX-Request": "true", "HX-History-Restore-Request": "true"}, false},
+		{map[string]string{"X-Requested-With": "xmlhttprequest"}, true},
+		{map[string]string{"HX-Request": "false"}, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		for k, v := range tt.headers {
+			r.Header.Set(k, v)
+		}
+		if got := IsFragmentRequest(r); got != tt.want {
+			t.Errorf("IsFragmentRequest(%v) = %v, want %v", tt.headers, got, tt.want)
+		}
+	}
+}
+
+func TestFragmentDetector(t *testing.T) {
+	h := newTestEngine(t, layoutFiles, WithLayoutDir("layouts"), WithDefaultLayout("base"),
+		WithFragmentDetector(func(r *http.Request) bool { return r.URL.Query().Has("partial") }))
+
+	for target, want := range map[string]string{"/": "<main>page</main>", "/?partial": "page"} {
+		w := httptest.NewRecorder()
+		if err := h.RenderHTTPWithLayout(w, httptest.NewRequest("GET", target, nil), &RenderData{View: "page.html"}); err != nil {
+			t.Fatal(err)
+		}
+		if got := w.Body.String(); got != want {
+			t.Errorf("%s got %q, want %q", target, got, want)
+		}
+		if got := w.Header().Values("Vary"); !slices.Equal(got, []string{"HX-Request"}) {
+			t.Errorf("%s: Vary %q", target, got)
+		}
+	}
+
+	h = newTestEngine(t, layoutFiles, WithLayoutDir("layouts"), WithDefaultLayout("base"),
+		WithFragmentDetector(func(r *http.Request) bool { return r.Header.Get("X-Partial") != "" }, "X-Partial"))
+	w := httptest.NewRecorder()
+	if err := h.RenderHTTPWithLayout(w, httptest.NewRequest("GET", "/", nil), &RenderData{View: "page.html"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Header().Values("Vary"); !slices.Equal(got, []string{"X-Partial"}) {
+		t.Fatalf("declared headers: Vary %q", got)
+	}
+}
+
+func TestRenderHeaders(t *testing.T) {
+	files := map[string]string{"page.html": `<p>{{.}}</p>`}
+	render := func(h *HTMLTemplate) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		if err := h.RenderHTTP(w, httptest.NewRequest("GET", "/", nil), "page.html", "hi"); err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+
+	captureLogs(t)
+	w := render(newTestEngine(t, files, WithDevelopment(true), WithRenderHeaders(true)))
+	if w.Header().Get("X-Render-Size") != "9" || w.Header().Get("X-Render-Time") == "" || w.Body.String() != "<p>hi</p>" {
+		t.Fatalf("headers %v, body %q", w.Header(), w.Body.String())
+	}
+	if st := w.Header().Get("Server-Timing"); !strings.HasPrefix(st, "render;dur=") {
+		t.Fatalf("Server-Timing %q", st)
+	}
+
+	// Never outside development mode
+	w = render(newTestEngine(t, files, WithRenderHeaders(true)))
+	for _, k := range []string{"X-Render-Time", "X-Render-Size", "X-Render-Mode", "Server-Timing"} {
+		if v := w.Header().Get(k); v != "" {
+			t.Errorf("production sent %s: %q", k, v)
+		}
+	}
+}
+
+func TestRenderNegotiated(t *testing.T) {
+	h := newTestEngine(t, map[string]string{"user.html": `<p>{{.Name}}</p>`})
+	data := map[string]string{"Name": "<Ann>"}
+
+	tests := []struct {
+		accept, contentType, body string
+	}{
+		{"application/json", "application/json; charset=utf-8", `{"Name":"\u003cAnn\u003e"}` + "\n"},
+		{"text/html,application/json;q=0.9", "text/html; charset=utf-8", "<p>&lt;Ann&gt;</p>"},
+		{"application/json, text/html;q=0.5", "application/json; charset=utf-8", `{"Name":"\u003cAnn\u003e"}` + "\n"},
+		{"application/json, */*", "application/json; charset=utf-8", `{"Name":"\u003cAnn\u003e"}` + "\n"},
+		{"text/html, application/json", "text/html; charset=utf-8", "<p>&lt;Ann&gt;</p>"},
+		{"*/*", "text/html; charset=utf-8", "<p>&lt;Ann&gt;</p>"},
+		{"", "text/html; charset=utf-8", "<p>&lt;Ann&gt;</p>"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		if err := h.RenderNegotiated(w, r, "user.html", data); err != nil {
+			t.Fatal(err)
+		}
+		if got := w.Header().Get("Content-Type"); got != tt.contentType {
+			t.Errorf("Accept %q: Content-Type %q, want %q", tt.accept, got, tt.contentType)
+		}
+		if got := w.Body.String(); got != tt.body {
+			t.Errorf("Accept %q: body %q, want %q", tt.accept, got, tt.body)
+		}
+		if !strings.Contains(strings.Join(w.Header().Values("Vary"), ","), "Accept") {
+			t.Errorf("Accept %q: no Vary: Accept", tt.accept)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package html
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// humanizeStrings are the English defaults for the humanizing helpers.
+// Each can be overridden per language by a translation with the same key.
+var humanizeStrings = map[string]string{
+	"timeAgo.now":     "just now",
+	"timeAgo.past":    "%s ago",
+	"timeAgo.future":  "in %s",
+	"timeAgo.second":  "%d second",
+	"timeAgo.seconds": "%d seconds",
+	"timeAgo.minute":  "%d minute",
+	"timeAgo.minutes": "%d minutes",
+	"timeAgo.hour":    "%d hour",
+	"timeAgo.hours":   "%d hours",
+	"timeAgo.day":     "%d day",
+	"timeAgo.days":    "%d days",
+	"timeAgo.layout":  "2006-01-02",
+	"number.decimal":  ".",
+	"duration.d":      "%dd",
+	"duration.h":      "%dh",
+	"duration.m":      "%dm",
+	"duration.s":      "%ds",
+	"duration.ms":     "%dms",
+}
+
+var (
+	iecUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	siUnits  = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+)
+
+// timeAgoCutoff is the distance beyond which timeAgo prints an absolute
+// date instead of a relative one
+const timeAgoCutoff = 30 * 24 * time.Hour
+
+// humanizeString returns the translation of key in lang, or the current
+// language when lang is empty, falling back to the English default
+func (c *Config) humanizeString(lang, key string) string {
+	if i := c.I18n; i != nil {
+		i.mu.RLock()
+		if lang == "" {
+			lang = i.lang()
+		}
+		s, ok := i.translator().Translate(lang, key)
+		i.mu.RUnlock()
+		if ok {
+			return s
+		}
+	}
+	return humanizeStrings[key]
+}
+
+// timeAgo formats t relative to now, such as "3 minutes ago" or "in 2
+// hours", localized through the timeAgo.* translation keys. Times further
+// than 30 days away are formatted with the timeAgo.layout layout in the
+// configured timezone.
+func (c *Config) timeAgo(t time.Time) string {
+	return c.timeAgoIn("", t, c.location())
+}
+
+// timeAgoIn is timeAgo in lang, or the current language when lang is
+// empty, with absolute dates formatted in loc
+func (c *Config) timeAgoIn(lang string, t time.Time, loc *time.Location) string {
+	d := time.Until(t).Round(time.Second)
+	future := d > 0
+	if !future {
+		d = -d
+	}
+
+	if d >= timeAgoCutoff {
+		return t.In(loc).Format(c.humanizeString(lang, "timeAgo.layout"))
+	}
+	if d < time.Second {
+		return c.humanizeString(lang, "timeAgo.now")
+	}
+
+	var n int
+	var unit string
+	switch {
+	case d < time.Minute:
+		n, unit = int(d/time.Second), "second"
+	case d < time.Hour:
+		n, unit = int(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		n, unit = int(d/time.Hour), "hour"
+	default:
+		n, unit = int(d/(24*time.Hour)), "day"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+
+	amount := fmt.Sprintf(c.humanizeString(lang, "timeAgo."+unit), n)
+	if future {
+		return fmt.Sprintf(c.humanizeString(lang, "timeAgo.future"), amount)
+	}
+	return fmt.Sprintf(c.humanizeString(lang, "timeAgo.past"), amount)
+}
+
+// humanBytes formats a byte count with binary units, 1536 -> "1.5 KiB", or
+// with decimal SI units when si is true, 1500 -> "1.5 kB". Unit symbols can
+// be translated with the bytes.<unit> keys and the decimal mark with
+// number.decimal. Counts that aren't finite or exceed 2^64 are an error.
+func (c *Config) humanBytes(n any, si ...bool) (string, error) {
+	return c.humanBytesIn("", n, si...)
+}
+
+// humanBytesIn is humanBytes in lang, or the current language when lang
+// is empty
+func (c *Config) humanBytesIn(lang string, n any, si ...bool) (string, error) {
+	f, err := toFloat64(n)
+	if err != nil {
+		return "", fmt.Errorf("humanBytes: %w", err)
+	}
+	if math.IsNaN(f) || math.Abs(f) > 1<<64 {
+		return "", fmt.Errorf("humanBytes: %v is out of range", n)
+	}
+
+	base, units := 1024.0, iecUnits
+	if len(si) > 0 && si[0] {
+		base, units = 1000, siUnits
+	}
+
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+
+	i := 0
+	for f >= base && i < len(units)-1 {
+		f /= base
+		i++
+	}
+
+	// One decimal is enough to tell sizes apart. Rounding can reach the
+	// next unit, 1023.96 KiB -> "1 MiB"
+	f = roundBytes(f, i)
+	if f >= base && i < len(units)-1 {
+		f = roundBytes(f/base, i+1)
+		i++
+	}
+
+	unit := units[i]
+	if s := c.humanizeString(lang, "bytes."+unit); s != "" {
+		unit = s
+	}
+	if i == 0 {
+		return sign + strconv.FormatFloat(f, 'f', 0, 64) + " " + unit, nil
+	}
+
+	num := strconv.FormatFloat(f, 'f', 1, 64)
+	num = strings.TrimSuffix(num, ".0")
+	num = strings.Replace(num, ".", c.humanizeString(lang, "number.decimal"), 1)
+	return sign + num + " " + unit, nil
+}
+
+// roundBytes rounds a size in the unit with index i, bytes to whole
+// numbers and larger units to one decimal
+func roundBytes(f float64, i int) float64 {
+	if i == 0 {
+		return math.Round(f)
+	}
+	return math.Round(f*10) / 10
+}
+
+// humanDuration formats a duration as its non-zero components, 90s -> "1m
+// 30s". Numbers are taken as seconds. Durations under a second are shown
+// in milliseconds. Unit formats can be translated with the duration.<unit>
+// keys. Values beyond the range of time.Duration, about 292 years, are an
+// error.
+func (c *Config) humanDuration(v any) (string, error) {
+	return c.humanDurationIn("", v)
+}
+
+// humanDurationIn is humanDuration in lang, or the current language when
+// lang is empty
+func (c *Config) humanDurationIn(lang string, v any) (string, error) {
+	var d time.Duration
+	switch v := v.(type) {
+	case time.Duration:
+		d = v
+	default:
+		f, err := toFloat64(v)
+		if err != nil {
+			return "", fmt.Errorf("humanDuration: %w", err)
+		}
+		ns := f * float64(time.Second)
+		if math.IsNaN(ns) || ns >= 1<<63 || ns <= -(1<<63) {
+			return "", fmt.Errorf("humanDuration: %v seconds is out of range", v)
+		}
+		d = time.Duration(ns)
+	}
+	if d == math.MinInt64 {
+		return "", fmt.Errorf("humanDuration: %v is out of range", d)
+	}
+
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+
+	if d == 0 {
+		return fmt.Sprintf(c.humanizeString(lang, "duration.s"), 0), nil
+	}
+	if d < time.Second {
+		return sign + fmt.Sprintf(c.humanizeString(lang, "duration.ms"), d.Milliseconds()), nil
+	}
+
+	var parts []string
+	for _, u := range []struct {
+		key  string
+		size time.Duration
+	}{
+		{"duration.d", 24 * time.Hour},
+		{"duration.h", time.Hour},
+		{"duration.m", time.Minute},
+		{"duration.s", time.Second},
+	} {
+		if n := d / u.size; n > 0 {
+			parts = append(parts, fmt.Sprintf(c.humanizeString(lang, u.key), int64(n)))
+			d -= n * u.size
+		}
+	}
+	return sign + strings.Join(parts, " "), nil
+}
@@ -0,0 +1,106 @@
+package html
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHumanBytes(t *testing.T) {
+	c := Sparkle("*.html").(*html).config
+	tests := []struct {
+		n    any
+		si   bool
+		want string
+	}{
+		{0, false, "0 B"},
+		{1023, false, "1023 B"},
+		{1023.7, false, "1 KiB"},
+		{1536, false, "1.5 KiB"},
+		{1024 * 1024, false, "1 MiB"},
+		{1024*1024 - 1, false, "1 MiB"},
+		{1024*1024*1024 - 1, false, "1 GiB"},
+		{-2048, false, "-2 KiB"},
+		{1500, true, "1.5 kB"},
+		{999_999, true, "1 MB"},
+		{int64(1) << 62, false, "4 EiB"},
+		{uint64(math.MaxUint64), false, "16 EiB"},
+	}
+	for _, tt := range tests {
+		got, err := c.humanBytes(tt.n, tt.si)
+		if err != nil || got != tt.want {
+			t.Errorf("humanBytes(%v, %v) = %q, %v, want %q", tt.n, tt.si, got, err, tt.want)
+		}
+	}
+	for _, n := range []any{"x", math.Inf(1), math.Inf(-1), math.NaN(), math.MaxFloat64, 1e20} {
+		if got, err := c.humanBytes(n); err == nil {
+			t.Errorf("humanBytes(%v) = %q, want an error", n, got)
+		}
+	}
+}
+
+func TestTimeAgo(t *testing.T) {
+	c := Sparkle("*.html").(*html).config
+	now := time.Now()
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "just now"},
+		{-time.Second - 100*time.Millisecond, "1 second ago"},
+		{-5*time.Minute - time.Second, "5 minutes ago"},
+		{-time.Hour - time.Second, "1 hour ago"},
+		{2*time.Hour + time.Second, "in 2 hours"},
+		{-3*24*time.Hour - time.Second, "3 days ago"},
+	}
+	for _, tt := range tests {
+		if got := c.timeAgo(now.Add(tt.d)); got != tt.want {
+			t.Errorf("timeAgo(now%+v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+
+	old := time.Date(2020, 3, 1, 23, 30, 0, 0, time.UTC)
+	if got := c.timeAgoIn("", old, time.FixedZone("east", 3600)); got != "2020-03-02" {
+		t.Errorf("absolute date got %q, want it in the given zone", got)
+	}
+}
+
+func TestTimeAgoTranslated(t *testing.T) {
+	c := Sparkle("*.html", WithI18n("de", testTranslations)).(*html).config
+	if got := c.timeAgo(time.Now().Add(-10*time.Minute - time.Second)); got != "vor 10 Minuten" {
+		t.Errorf("got %q", got)
+	}
+	// Keys without a translation fall back to English
+	if got := c.timeAgo(time.Now().Add(-2*time.Hour - time.Second)); got != "vor 2 hours" {
+		t.Errorf("untranslated unit got %q", got)
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	c := Sparkle("*.html").(*html).config
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{0, "0s"},
+		{90, "1m 30s"},
+		{1.5, "1s"},
+		{0.25, "250ms"},
+		{-3600, "-1h"},
+		{26*time.Hour + 5*time.Second, "1d 2h 5s"},
+		{time.Duration(0), "0s"},
+		{9e9, "104166d 16h"},
+		{time.Duration(math.MaxInt64), "106751d 23h 47m 16s"},
+	}
+	for _, tt := range tests {
+		got, err := c.humanDuration(tt.v)
+		if err != nil || got != tt.want {
+			t.Errorf("humanDuration(%v) = %q, %v, want %q", tt.v, got, err, tt.want)
+		}
+	}
+	for _, v := range []any{"soon", 1e12, -1e12, uint64(math.MaxUint64), math.MaxFloat64, math.Inf(1), math.NaN(), time.Duration(math.MinInt64)} {
+		if got, err := c.humanDuration(v); err == nil {
+			t.Errorf("humanDuration(%v) = %q, want an error", v, got)
+		}
+	}
+}
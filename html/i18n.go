@@ -0,0 +1,201 @@
+package html
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"text/template/parse"
+)
+
+// LangOption describes one entry of a language switcher
+type LangOption struct {
+	Code       string
+	NativeName string
+	Active     bool
+}
+
+// nativeNames holds the built-in native names of common languages.
+// I18nConfig.NativeNames takes precedence over it.
+var nativeNames = map[string]string{
+	"ar":    "العربية",
+	"cs":    "Čeština",
+	"da":    "Dansk",
+	"de":    "Deutsch",
+	"el":    "Ελληνικά",
+	"en":    "English",
+	"es":    "Español",
+	"fi":    "Suomi",
+	"fr":    "Français",
+	"he":    "עברית",
+	"hi":    "हिन्दी",
+	"hu":    "Magyar",
+	"id":    "Bahasa Indonesia",
+	"it":    "Italiano",
+	"ja":    "日本語",
+	"ko":    "한국어",
+	"nl":    "Nederlands",
+	"no":    "Norsk",
+	"pl":    "Polski",
+	"pt":    "Português",
+	"pt-BR": "Português (Brasil)",
+	"ro":    "Română",
+	"ru":    "Русский",
+	"sv":    "Svenska",
+	"th":    "ไทย",
+	"tr":    "Türkçe",
+	"uk":    "Українська",
+	"vi":    "Tiếng Việt",
+	"zh":    "中文",
+	"zh-CN": "简体中文",
+	"zh-TW": "繁體中文",
+}
+
+// Translator looks up the translation of key in lang and formats it with
+// args. Implement it to serve translations from a database or a
+// translation service; see WithTranslator.
+type Translator interface {
+	Translate(lang, key string, args ...any) (string, bool)
+}
+
+// MapTranslator is the default Translator, mapping language codes to keys
+// to fmt format strings
+type MapTranslator map[string]map[string]string
+
+// Translate implements Translator
+func (m MapTranslator) Translate(lang, key string, args ...any) (string, bool) {
+	translation, ok := m[lang][key]
+	if !ok {
+		return "", false
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(translation, args...), true
+	}
+	return translation, true
+}
+
+// Languages returns the language codes with translations
+func (m MapTranslator) Languages() []string {
+	return slices.Collect(maps.Keys(m))
+}
+
+// translator returns the configured Translator, or Translations as one
+func (i *I18nConfig) translator() Translator {
+	if i.Translator != nil {
+		return i.Translator
+	}
+	return MapTranslator(i.Translations)
+}
+
+// LanguageOptions returns the configured languages sorted by code, with
+// current marked active. Custom translators list theirs via Languages().
+func (i *I18nConfig) LanguageOptions(current string) []LangOption {
+	if i == nil {
+		return nil
+	}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var codes []string
+	if l, ok := i.translator().(interface{ Languages() []string }); ok {
+		codes = slices.Sorted(slices.Values(l.Languages()))
+	}
+
+	options := make([]LangOption, len(codes))
+	for n, code := range codes {
+		options[n] = LangOption{
+			Code:       code,
+			NativeName: i.nativeName(code),
+			Active:     code == current,
+		}
+	}
+	return options
+}
+
+// nativeName returns the name of the language in that language
+func (i *I18nConfig) nativeName(code string) string {
+	if name, ok := i.NativeNames[code]; ok {
+		return name
+	}
+	if name, ok := nativeNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+// translateFuncs are the helpers whose first argument is a translation key
+var translateFuncs = []string{"t", "tn", "tl", "tp"}
+
+// placeholder matches a named placeholder such as {name}
+var placeholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// interpolate replaces each {name} in s with values[name], leaving
+// placeholders without a value in place
+func interpolate(s string, values map[string]any) string {
+	return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		v, ok := values[m[1:len(m)-1]]
+		if !ok {
+			return m
+		}
+		return fmt.Sprint(v)
+	})
+}
+
+// ExtractMessages returns the sorted translation keys the templates use,
+// for diffing against translation bundles to find missing and unused
+// entries. Only keys written as string literals are found; keys computed
+// at render time are skipped.
+func (h *HTMLTemplate) ExtractMessages() ([]string, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.t == nil {
+		return nil, errors.New("no templates loaded")
+	}
+
+	var keys []string
+	for _, tpl := range h.t.Templates() {
+		if tpl.Tree == nil {
+			continue
+		}
+		walkTree(tpl.Tree.Root, func(node parse.Node) bool {
+			n, ok := node.(*parse.CommandNode)
+			if !ok || len(n.Args) < 2 {
+				return true
+			}
+			if fn, ok := n.Args[0].(*parse.IdentifierNode); ok && slices.Contains(translateFuncs, fn.Ident) {
+				if key, ok := n.Args[1].(*parse.StringNode); ok {
+					keys = append(keys, key.Text)
+				}
+			}
+			return true
+		})
+	}
+
+	slices.Sort(keys)
+	return slices.Compact(keys), nil
+}
+
+// MissingTranslations returns the sorted translation keys the templates use
+// that lang has no translation for, as found by ExtractMessages. Keys
+// computed at render time are not checked.
+func (h *HTMLTemplate) MissingTranslations(lang string) ([]string, error) {
+	if h.config.I18n == nil {
+		return nil, errors.New("i18n not configured")
+	}
+
+	keys, err := h.ExtractMessages()
+	if err != nil {
+		return nil, err
+	}
+
+	i := h.config.I18n
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return slices.DeleteFunc(keys, func(key string) bool {
+		_, ok := i.translator().Translate(lang, key)
+		return ok
+	}), nil
+}
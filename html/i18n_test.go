@@ -0,0 +1,159 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLanguageOptions(t *testing.T) {
+	i := &I18nConfig{
+		Translations: map[string]map[string]string{"en": {}, "de": {}, "xx": {}, "fr": {}},
+		NativeNames:  map[string]string{"fr": "Français (France)"},
+	}
+	want := []LangOption{
+		{Code: "de", NativeName: "Deutsch", Active: true},
+		{Code: "en", NativeName: "English"},
+		{Code: "fr", NativeName: "Français (France)"},
+		{Code: "xx", NativeName: "xx"},
+	}
+	if got := i.LanguageOptions("de"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	var none *I18nConfig
+	if got := none.LanguageOptions("en"); got != nil {
+		t.Fatalf("nil config got %+v", got)
+	}
+}
+
+// prefixTranslator translates every key except "missing" by tagging it
+// with the language
+type prefixTranslator struct{}
+
+func (prefixTranslator) Translate(lang, key string, args ...any) (string, bool) {
+	if key == "missing" {
+		return "", false
+	}
+	return lang + ":" + key + fmt.Sprint(args...), true
+}
+
+func TestTranslator(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{t "hello"}} {{t "n" 3}} {{t "missing"}} {{timeAgo .}}`,
+	}, WithI18n("fr", nil), WithTranslator(prefixTranslator{}))
+
+	if got := renderString(t, h, "page.html", time.Now()); got != "fr:hello fr:n3 missing fr:timeAgo.now" {
+		t.Fatalf("got %q", got)
+	}
+	if got := h.config.I18n.LanguageOptions("fr"); len(got) != 0 {
+		t.Fatalf("translator without Languages listed %v", got)
+	}
+}
+
+func TestExtractMessages(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":  `{{t "hello"}} {{tn "items" 2}} {{t .Key}} {{if .}}{{tp "nested" .}}{{end}}`,
+		"other.html": `{{define "footer"}}{{t "hello"}}{{end}}{{print "plain"}}`,
+	}, WithI18n("en", map[string]map[string]string{"en": {"hello": "Hello"}}),
+		WithFuncs(template.FuncMap{"tn": func(key string, n int) string { return key }}))
+
+	keys, err := h.ExtractMessages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"hello", "items", "nested"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("got %v, want %v", keys, want)
+	}
+}
+
+func TestTranslateNamed(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{tp "greeting" (dict "name" .User "count" .N)}}|{{tp "greeting" (dict "name" .User)}}|{{t "plain" .N}}`,
+	}, WithI18n("en", map[string]map[string]string{
+		"en": {"greeting": "Hello {name}, you have {count} messages", "plain": "%d new"},
+		"de": {"greeting": "{count} Nachrichten für {name}", "plain": "%d neu"},
+	}))
+	data := map[string]any{"User": "Ann", "N": 3}
+
+	if got := renderString(t, h, "page.html", data); got != "Hello Ann, you have 3 messages|Hello Ann, you have {count} messages|3 new" {
+		t.Fatalf("en got %q", got)
+	}
+	var buf bytes.Buffer
+	if err := h.RenderContext(ContextWithLang(context.Background(), "de"), &buf, "page.html", data); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "3 Nachrichten für Ann|{count} Nachrichten für Ann|3 neu" {
+		t.Fatalf("de got %q", got)
+	}
+}
+
+func TestMissingTranslations(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{t "hello"}} {{t "bye"}} {{t .Key}} {{tp "welcome" .}}`,
+	}, WithI18n("en", map[string]map[string]string{
+		"en": {"hello": "Hello", "bye": "Bye", "welcome": "Welcome {name}"},
+		"fr": {"hello": "Bonjour"},
+	}))
+
+	tests := map[string][]string{
+		"en": {},
+		"fr": {"bye", "welcome"},
+		"de": {"bye", "hello", "welcome"},
+	}
+	for lang, want := range tests {
+		got, err := h.MissingTranslations(lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: got %v, want %v", lang, got, want)
+		}
+	}
+
+	h = newTestEngine(t, map[string]string{"page.html": `page`})
+	if _, err := h.MissingTranslations("fr"); err == nil {
+		t.Fatal("without i18n: want an error")
+	}
+}
+
+func TestNilTranslations(t *testing.T) {
+	configs := map[string]*I18nConfig{
+		"nil config":  nil,
+		"empty":       {DefaultLang: "en"},
+		"nil bundle":  {DefaultLang: "fr", Translations: map[string]map[string]string{"fr": nil}},
+		"no language": {Translations: map[string]map[string]string{}},
+	}
+	for name, i := range configs {
+		if got := i.Translate("hello"); got != "hello" {
+			t.Errorf("%s: Translate got %q", name, got)
+		}
+		if got := i.translateIn("de", "hello"); got != "hello" {
+			t.Errorf("%s: translateIn got %q", name, got)
+		}
+		if got := i.TranslateNamed("Hi {name}", map[string]any{"name": "Ann"}); got != "Hi Ann" {
+			t.Errorf("%s: TranslateNamed got %q", name, got)
+		}
+		i.SetLanguage("de")
+		if i != nil && i.CurrentLanguage() != "de" {
+			t.Errorf("%s: SetLanguage was not applied", name)
+		}
+		_ = i.LanguageOptions("de")
+		_ = i.languages()
+	}
+	if got := (*I18nConfig)(nil).CurrentLanguage(); got != "" {
+		t.Fatalf("nil config: CurrentLanguage got %q", got)
+	}
+
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{t "hello"}} {{tp "Hi {name}" .}}`,
+	}, WithI18n("en", nil))
+	if got := renderString(t, h, "page.html", map[string]any{"name": "Ann"}); got != "hello Hi Ann" {
+		t.Fatalf("render got %q", got)
+	}
+}
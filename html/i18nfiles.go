@@ -0,0 +1,144 @@
+package html
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// loadDir reads the translation files in Dir, one JSON object of keys to
+// messages per language, named after it: en.json, fr.json
+func (i *I18nConfig) loadDir() (map[string]map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(i.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	translations := map[string]map[string]string{}
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(src, &messages); err != nil {
+			return nil, fmt.Errorf("translations %s: %w", file, err)
+		}
+		translations[strings.TrimSuffix(filepath.Base(file), ".json")] = messages
+	}
+	return translations, nil
+}
+
+// reloadDir replaces the translations of the languages in Dir with the
+// current files and returns what changed, one line per language. Languages
+// only configured with WithI18n are kept, and a WithI18n language whose
+// file is removed gets its configured translations back.
+func (i *I18nConfig) reloadDir() ([]string, error) {
+	loaded, err := i.loadDir()
+	if err != nil {
+		return nil, err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var changes []string
+	for _, lang := range slices.Sorted(maps.Keys(loaded)) {
+		if change := translationChanges(lang, i.Translations[lang], loaded[lang]); change != "" {
+			changes = append(changes, change)
+		}
+	}
+
+	translations := maps.Clone(i.Translations)
+	if translations == nil {
+		translations = map[string]map[string]string{}
+	}
+	shadowed := maps.Clone(i.shadowed)
+	if shadowed == nil {
+		shadowed = map[string]map[string]string{}
+	}
+	for _, lang := range slices.Sorted(maps.Keys(i.dirLangs)) {
+		if _, ok := loaded[lang]; ok {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("%s: removed", lang))
+		if prev, ok := shadowed[lang]; ok {
+			translations[lang] = prev
+			delete(shadowed, lang)
+		} else {
+			delete(translations, lang)
+		}
+	}
+	for lang := range loaded {
+		if prev, ok := translations[lang]; ok && !i.dirLangs[lang] {
+			shadowed[lang] = prev
+		}
+	}
+	maps.Copy(translations, loaded)
+
+	i.Translations = translations
+	i.shadowed = shadowed
+	i.dirLangs = map[string]bool{}
+	for lang := range loaded {
+		i.dirLangs[lang] = true
+	}
+	return changes, nil
+}
+
+// translationChanges describes the keys added, removed and modified
+// between two versions of a language's messages, or returns "" when they
+// are the same
+func translationChanges(lang string, old, cur map[string]string) string {
+	var added, removed, modified []string
+	for key, msg := range cur {
+		prev, ok := old[key]
+		switch {
+		case !ok:
+			added = append(added, key)
+		case prev != msg:
+			modified = append(modified, key)
+		}
+	}
+	for key := range old {
+		if _, ok := cur[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+
+	var parts []string
+	for _, p := range []struct {
+		what string
+		keys []string
+	}{{"added", added}, {"removed", removed}, {"modified", modified}} {
+		if len(p.keys) > 0 {
+			slices.Sort(p.keys)
+			parts = append(parts, p.what+" "+strings.Join(p.keys, ", "))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return lang + ": " + strings.Join(parts, "; ")
+}
+
+// reloadTranslations rereads the translation files in development mode and
+// logs what changed
+func (h *HTMLTemplate) reloadTranslations() error {
+	i := h.config.I18n
+	if i == nil || i.Dir == "" {
+		return nil
+	}
+	changes, err := i.reloadDir()
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		log.Printf("Translations changed %s", change)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package html
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestI18nDirReload(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "i18n")
+	writeFiles(t, dir, map[string]string{
+		"fr.json": `{"hello": "Bonjour", "bye": "Au revoir", "old": "Vieux"}`,
+		"es.json": `{"hello": "Hola"}`,
+	})
+	logs := captureLogs(t)
+	h := newTestEngine(t, map[string]string{"page.html": `{{t "hello"}}`},
+		WithI18n("fr", map[string]map[string]string{"en": {"hello": "Hello"}}), WithI18nDir(dir), WithDevelopment(true))
+
+	if got := renderString(t, h, "page.html", nil); got != "Bonjour" {
+		t.Fatalf("got %q", got)
+	}
+	if strings.Contains(logs.String(), "Translations changed") {
+		t.Fatalf("unchanged files logged %q", logs.String())
+	}
+
+	writeFiles(t, dir, map[string]string{"fr.json": `{"hello": "Salut", "bye": "Au revoir", "new": "Nouveau"}`})
+	if err := h.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := renderString(t, h, "page.html", nil); got != "Salut" {
+		t.Fatalf("after reload got %q", got)
+	}
+	if want := "Translations changed fr: added new; removed old; modified hello"; !strings.Contains(logs.String(), want) {
+		t.Fatalf("no %q in %q", want, logs.String())
+	}
+	if strings.Contains(logs.String(), "changed es") || strings.Contains(logs.String(), "changed en") {
+		t.Fatalf("unchanged languages logged %q", logs.String())
+	}
+}
+
+func TestI18nDirRemovedFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "i18n")
+	writeFiles(t, dir, map[string]string{
+		"fr.json": `{"hello": "Bonjour"}`,
+		"es.json": `{"hello": "Hola"}`,
+	})
+	captureLogs(t)
+	h := newTestEngine(t, map[string]string{"page.html": `{{t "hello"}}`},
+		WithI18n("fr", map[string]map[string]string{"fr": {"hello": "Allô"}}), WithI18nDir(dir), WithDevelopment(true))
+
+	if got := renderString(t, h, "page.html", nil); got != "Bonjour" {
+		t.Fatalf("got %q, want the file to win", got)
+	}
+
+	for _, file := range []string{"fr.json", "es.json"} {
+		if err := os.Remove(filepath.Join(dir, file)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := renderString(t, h, "page.html", nil); got != "Allô" {
+		t.Fatalf("after removing fr.json got %q, want the WithI18n translation", got)
+	}
+	if langs := h.config.I18n.languages(); !slices.Equal(langs, []string{"fr"}) {
+		t.Fatalf("languages %v, want es gone and fr kept", langs)
+	}
+}
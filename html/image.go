@@ -0,0 +1,53 @@
+package html
+
+import (
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+)
+
+// imageVariant returns the asset name of the width-pixel variant of name,
+// "app-320w.jpg" for "app.jpg"
+func imageVariant(name string, width int) string {
+	ext := filepath.Ext(name)
+	return fmt.Sprintf("%s-%dw%s", strings.TrimSuffix(name, ext), width, ext)
+}
+
+// srcset returns the srcset value listing the variants of the image asset
+// name at widths, each with its versioned URL:
+//
+//	<img src="{{asset "hero.jpg"}}" srcset="{{srcset "hero.jpg" 320 640}}">
+//
+// Variants are named after the width, "hero-320w.jpg". Widths without a
+// variant file in the asset directory are skipped.
+func (c *Config) srcset(name string, widths ...int) template.Srcset {
+	var candidates []string
+	for _, w := range widths {
+		variant := imageVariant(name, w)
+		f, err := c.openAsset(variant)
+		if err != nil {
+			continue
+		}
+		f.Close()
+		candidates = append(candidates, fmt.Sprintf("%s %dw", c.assetPath(variant), w))
+	}
+	return template.Srcset(strings.Join(candidates, ", "))
+}
+
+// image returns a responsive <img> for the image asset name, with src set
+// to the asset itself and srcset listing its variants at widths, as by
+// srcset. sizes is left out when empty. Without any variant the srcset
+// attribute is omitted and the plain image is used.
+func (c *Config) image(name, alt, sizes string, widths ...int) template.HTML {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<img src="%s"`, template.HTMLEscapeString(c.assetPath(name)))
+	if set := c.srcset(name, widths...); set != "" {
+		fmt.Fprintf(&b, ` srcset="%s"`, template.HTMLEscapeString(string(set)))
+		if sizes != "" {
+			fmt.Fprintf(&b, ` sizes="%s"`, template.HTMLEscapeString(sizes))
+		}
+	}
+	fmt.Fprintf(&b, ` alt="%s">`, template.HTMLEscapeString(alt))
+	return template.HTML(b.String())
+}
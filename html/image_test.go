@@ -0,0 +1,37 @@
+package html
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSrcset(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"assets/hero.jpg":      "x",
+		"assets/hero-320w.jpg": "x",
+		"assets/hero-640w.jpg": "x",
+		"secret-320w.txt":      "x",
+		"assets/plain.jpg":     "x",
+	})
+	assets := filepath.Join(dir, "assets")
+	h := newTestEngine(t, map[string]string{
+		"img.html":    `<img srcset="{{srcset "hero.jpg" 320 640 1280}}">`,
+		"full.html":   `{{image "hero.jpg" "A & B" "50vw" 320 640}}`,
+		"plain.html":  `{{image "plain.jpg" "plain" "50vw" 320}}`,
+		"escape.html": `<img srcset="{{srcset "../secret.txt" 320}}">`,
+	}, WithAssetDir(assets), WithAssetVersion("7"))
+
+	hero := func(w string) string { return filepath.Join(assets, "hero-"+w+".jpg") + "?v=7" }
+	tests := map[string]string{
+		"img.html":    `<img srcset="` + hero("320w") + ` 320w, ` + hero("640w") + ` 640w">`,
+		"full.html":   `<img src="` + filepath.Join(assets, "hero.jpg") + `?v=7" srcset="` + hero("320w") + ` 320w, ` + hero("640w") + ` 640w" sizes="50vw" alt="A &amp; B">`,
+		"plain.html":  `<img src="` + filepath.Join(assets, "plain.jpg") + `?v=7" alt="plain">`,
+		"escape.html": `<img srcset="">`,
+	}
+	for name, want := range tests {
+		if got := renderString(t, h, name, nil); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package html
+
+import (
+	"fmt"
+	"html/template"
+	"maps"
+	"reflect"
+	"slices"
+	"text/template/parse"
+)
+
+// RegisterStructTemplates adds a template for each string field of the
+// struct v, or of the struct v points to, tagged with a template name; the
+// field's value is the template source:
+//
+//	type Badge struct {
+//		Tmpl  string `tmpl:"badge"`
+//		Label string
+//	}
+//
+//	h.RegisterStructTemplates(Badge{Tmpl: `<span class="badge">{{.Label}}</span>`})
+//
+// Templates are named as tagged, plus any NamePrefix, and are parsed
+// again on every reload.
+func (h *HTMLTemplate) RegisterStructTemplates(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("struct templates: expected a struct, got %T", v)
+	}
+
+	sources := map[string]string{}
+	for i := range rv.NumField() {
+		field := rv.Type().Field(i)
+		name, ok := field.Tag.Lookup("tmpl")
+		if !ok {
+			continue
+		}
+		if name == "" {
+			return fmt.Errorf("struct templates: field %s has an empty template name", field.Name)
+		}
+		if field.Type.Kind() != reflect.String {
+			return fmt.Errorf("struct templates: field %s holds %s, not template source", field.Name, field.Type)
+		}
+		sources[name] = rv.Field(i).String()
+	}
+
+	h.mu.Lock()
+	old := h.config.inline
+	inline := maps.Clone(old)
+	if inline == nil {
+		inline = map[string]string{}
+	}
+	maps.Copy(inline, sources)
+	h.config.inline = inline
+	h.cfgGen++
+	h.mu.Unlock()
+
+	if err := h.reload(); err != nil {
+		h.mu.Lock()
+		h.config.inline = old
+		h.cfgGen++
+		h.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// parseInline parses the templates registered with RegisterStructTemplates
+// and AddTemplate into t
+func (c *Config) parseInline(t *template.Template) error {
+	for _, name := range slices.Sorted(maps.Keys(c.inline)) {
+		if t.Lookup(name) != nil && !c.Overwrite {
+			return fmt.Errorf("template %s: already defined", name)
+		}
+		if _, err := t.New(name).Parse(c.inline[name]); err != nil {
+			return fmt.Errorf("template %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// AddTemplate parses source as a template named name, plus NamePrefix, and
+// swaps it into a running engine, for plugins. The source is parsed again
+// on every reload.
+func (h *HTMLTemplate) AddTemplate(name, source string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := h.config
+	funcs, stubs := c.mergeFuncs(), template.FuncMap{}
+	if !c.FuncCheck {
+		c.stubFuncs(stubs, name, source, funcs)
+		maps.Copy(funcs, stubs)
+	}
+	nt, err := template.New(name).Delims(c.Delimiters[0], c.Delimiters[1]).Funcs(funcs).Parse(source)
+	if err != nil {
+		return fmt.Errorf("template %s: %w", name, err)
+	}
+	if c.AutoTrim {
+		autoTrim(nt)
+	}
+
+	t, err := h.base.Clone()
+	if err != nil {
+		return err
+	}
+	t.Funcs(stubs)
+
+	added := map[string]bool{}
+	for _, tpl := range nt.Templates() {
+		added[tpl.Name()] = true
+	}
+	for _, tpl := range nt.Templates() {
+		if tpl.Tree == nil {
+			continue
+		}
+		tree := tpl.Tree.Copy()
+		if c.NamePrefix != "" {
+			tree.Name = c.NamePrefix + tree.Name
+			walkTree(tree.Root, func(node parse.Node) bool {
+				if n, ok := node.(*parse.TemplateNode); ok && (added[n.Name] || t.Lookup(c.NamePrefix+n.Name) != nil) {
+					n.Name = c.NamePrefix + n.Name
+				}
+				return true
+			})
+		}
+		if t.Lookup(tree.Name) != nil && !c.Overwrite {
+			return fmt.Errorf("template %s: already defined", tree.Name)
+		}
+		if _, err := t.AddParseTree(tree.Name, tree); err != nil {
+			return fmt.Errorf("template %s: %w", name, err)
+		}
+	}
+
+	if err := h.swap(t); err != nil {
+		return err
+	}
+	inline := maps.Clone(c.inline)
+	if inline == nil {
+		inline = map[string]string{}
+	}
+	inline[name] = source
+	c.inline = inline
+	h.cfgGen++
+	return nil
+}
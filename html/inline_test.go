@@ -0,0 +1,165 @@
+package html
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAddTemplate(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `<p>{{template "plugin" .}}</p>`,
+	})
+
+	if err := h.AddTemplate("plugin", `{{define "item"}}<i>{{.}}</i>{{end}}{{template "item" .}}`); err != nil {
+		t.Fatal(err)
+	}
+	if got := renderString(t, h, "page.html", "x"); got != "<p><i>x</i></p>" {
+		t.Fatalf("got %q", got)
+	}
+
+	if err := h.AddTemplate("item", `again`); err == nil || !strings.Contains(err.Error(), "already defined") {
+		t.Fatalf("redefinition: got %v, want already defined", err)
+	}
+
+	if err := h.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := renderString(t, h, "page.html", "y"); got != "<p><i>y</i></p>" {
+		t.Fatalf("after reload got %q", got)
+	}
+}
+
+func TestAddTemplateNamePrefix(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `page`,
+	}, WithNamePrefix("app:"))
+
+	if err := h.AddTemplate("plugin", `{{define "item"}}<i>{{.}}</i>{{end}}{{template "item" .}}`); err != nil {
+		t.Fatal(err)
+	}
+	if got := renderString(t, h, "app:plugin", "x"); got != "<i>x</i>" {
+		t.Fatalf("got %q", got)
+	}
+	if h.t.Lookup("app:item") == nil {
+		t.Fatal("defined template not added with the name prefix")
+	}
+}
+
+func TestAddTemplateOverwrite(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `file`,
+	}, WithTemplateOverwrite(true))
+
+	if err := h.AddTemplate("page.html", `plugin`); err != nil {
+		t.Fatal(err)
+	}
+	if got := renderString(t, h, "page.html", nil); got != "plugin" {
+		t.Fatalf("got %q, want plugin", got)
+	}
+}
+
+func TestAddTemplateDuringReload(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `page`,
+	}, WithDevelopment(true))
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 20 {
+				if err := h.reload(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		})
+		wg.Go(func() {
+			for range 50 {
+				var buf bytes.Buffer
+				if err := h.Render(&buf, "page.html", nil); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		})
+	}
+	for i := range 20 {
+		if err := h.AddTemplate(fmt.Sprint("plugin", i), fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	for i := range 20 {
+		name := fmt.Sprint("plugin", i)
+		if got := renderString(t, h, name, nil); got != fmt.Sprint(i) {
+			t.Fatalf("%s: got %q, want %d", name, got, i)
+		}
+	}
+}
+
+type badge struct {
+	Tmpl  string `tmpl:"badge"`
+	Card  string `tmpl:"card"`
+	Label string
+}
+
+func TestRegisterStructTemplates(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{partial "card" .}}`,
+	})
+	err := h.RegisterStructTemplates(&badge{
+		Tmpl: `<span class="badge">{{.Label}}</span>`,
+		Card: `<div>{{template "badge" .}}</div>`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := badge{Label: "<new>"}
+	if got := renderString(t, h, "badge", data); got != `<span class="badge">&lt;new&gt;</span>` {
+		t.Fatalf("badge got %q", got)
+	}
+	if got := renderString(t, h, "page.html", data); got != `<div><span class="badge">&lt;new&gt;</span></div>` {
+		t.Fatalf("page got %q", got)
+	}
+
+	// Struct templates survive reloads
+	if err := h.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if !h.HasTemplate("card") {
+		t.Fatal("struct template lost on reload")
+	}
+}
+
+func TestRegisterStructTemplatesErrors(t *testing.T) {
+	h := newTestEngine(t, map[string]string{"page.html": `page`})
+
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{"<p>", "expected a struct, got string"},
+		{struct {
+			N int `tmpl:"n"`
+		}{}, "field N holds int"},
+		{struct {
+			S string `tmpl:""`
+		}{}, "field S has an empty template name"},
+		{struct {
+			S string `tmpl:"broken"`
+		}{S: "{{if}}"}, "broken"},
+	}
+	for _, tt := range tests {
+		if err := h.RegisterStructTemplates(tt.v); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("got %v, want %q", err, tt.want)
+		}
+	}
+	if h.HasTemplate("broken") || !h.HasTemplate("page.html") {
+		t.Fatal("a failed registration changed the templates")
+	}
+}
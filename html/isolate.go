@@ -0,0 +1,64 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// RenderIsolated renders like Render, but replaces failed partials and
+// embeds with the error placeholder and returns their errors separately
+func (h *HTMLTemplate) RenderIsolated(w io.Writer, name string, data any) ([]error, error) {
+	if h.config.Development {
+		if err := h.reloadIfNeeded(); err != nil {
+			return nil, fmt.Errorf("failed to reload templates: %w", err)
+		}
+	}
+
+	name = h.resolve(name)
+	if err := h.validateTemplate(name); err != nil {
+		return nil, err
+	}
+
+	data, err := h.config.transform(name, data)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = h.config.prepare(name, data); err != nil {
+		return nil, err
+	}
+
+	s, err := h.acquireSet(w, name)
+	if err != nil {
+		return nil, err
+	}
+	defer s.release()
+
+	s.rs.isolate = true
+	err = s.run(context.Background(), name, data, name)
+	return s.rs.isolated, err
+}
+
+// isolating wraps an include helper so that, in an isolated render, a
+// failure is recorded and the error placeholder is returned instead
+func (rs *renderState) isolating(kind, name string, out template.HTML, err error) (template.HTML, error) {
+	if err == nil || !rs.isolate {
+		return out, err
+	}
+
+	err = fmt.Errorf("%s %s: %w", kind, name, err)
+	rs.isolated = append(rs.isolated, err)
+
+	placeholder := rs.h.config.ErrorPlaceholder
+	if placeholder == "" {
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	if perr := rs.t.ExecuteTemplate(&buf, placeholder, map[string]any{"Error": err, "Template": name}); perr != nil {
+		return "", fmt.Errorf("error placeholder %s: %w", placeholder, perr)
+	}
+	return template.HTML(buf.String()), nil
+}
@@ -0,0 +1,48 @@
+package html
+
+import (
+	"strings"
+	"testing"
+)
+
+var isolateFiles = map[string]string{
+	"dash.html":   `<main>{{partial "ok.html"}}{{partial "broken.html"}}{{partial "missing.html"}}</main>`,
+	"ok.html":     `<p>ok</p>`,
+	"broken.html": `{{index . 5}}`,
+	"error.html":  `<p class="error">{{.Template}} failed</p>`,
+}
+
+func TestRenderIsolated(t *testing.T) {
+	h := newTestEngine(t, isolateFiles, WithErrorPlaceholder("error.html"))
+
+	var buf strings.Builder
+	errs, err := h.RenderIsolated(&buf, "dash.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<main><p>ok</p><p class="error">broken.html failed</p><p class="error">missing.html failed</p></main>`
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+	if len(errs) != 2 || !strings.HasPrefix(errs[0].Error(), "partial broken.html: ") || !strings.HasPrefix(errs[1].Error(), "partial missing.html: ") {
+		t.Fatalf("errors %v", errs)
+	}
+
+	// Render fails fast on the same page
+	if err := h.Render(&strings.Builder{}, "dash.html", nil); err == nil {
+		t.Fatal("Render: want an error")
+	}
+}
+
+func TestRenderIsolatedWithoutPlaceholder(t *testing.T) {
+	h := newTestEngine(t, isolateFiles)
+
+	var buf strings.Builder
+	errs, err := h.RenderIsolated(&buf, "dash.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "<main><p>ok</p></main>" || len(errs) != 2 {
+		t.Fatalf("got %q, %v", buf.String(), errs)
+	}
+}
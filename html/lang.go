@@ -0,0 +1,121 @@
+package html
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// langKey is the context key of the render language
+type langKey struct{}
+
+// ContextWithLang returns a copy of ctx that makes RenderContext render in
+// lang: translations, number and currency formats and default date
+// layouts use it instead of the engine's current language, which is left
+// untouched, so concurrent renders can each use their own.
+func ContextWithLang(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, langKey{}, lang)
+}
+
+// langFrom returns the render language carried by ctx, if any
+func langFrom(ctx context.Context) string {
+	lang, _ := ctx.Value(langKey{}).(string)
+	return lang
+}
+
+// requestLang returns the language to render r in when i18n is
+// configured: the WithLangCookie cookie's, if it names a known language,
+// else the best match for the Accept-Language header. With a cookie
+// configured and no match it is DefaultLang; otherwise it is empty and the
+// current language applies. The headers it consults are added to Vary, so
+// shared caches don't serve one language to everyone.
+func (h *HTMLTemplate) requestLang(w http.ResponseWriter, r *http.Request) string {
+	i := h.config.I18n
+	if i == nil {
+		return ""
+	}
+	langs := i.languages()
+	cookieName := h.config.LangCookie
+
+	var vary []string
+	if len(langs) > 0 {
+		vary = append(vary, "Accept-Language")
+	}
+	if cookieName != "" {
+		vary = append(vary, "Cookie")
+	}
+	addVary(w.Header(), vary...)
+
+	if cookieName != "" {
+		if cookie, err := r.Cookie(cookieName); err == nil && cookie.Value != "" {
+			if len(langs) == 0 || slices.Contains(langs, cookie.Value) {
+				return cookie.Value
+			}
+		}
+	}
+	if lang := matchAcceptLanguage(r.Header.Get("Accept-Language"), langs); lang != "" {
+		return lang
+	}
+	if cookieName == "" {
+		return ""
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.DefaultLang
+}
+
+// languages returns the codes the translator has translations for, or nil
+// when it can't list them
+func (i *I18nConfig) languages() []string {
+	if i == nil {
+		return nil
+	}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if l, ok := i.translator().(interface{ Languages() []string }); ok {
+		return l.Languages()
+	}
+	return nil
+}
+
+// matchAcceptLanguage returns the language of langs that the
+// Accept-Language header ranks highest. A tag matches a language of the
+// same code, case-insensitively, and failing that one of its base
+// language, "de-AT" matching "de".
+func matchAcceptLanguage(header string, langs []string) string {
+	type tag struct {
+		code string
+		q    float64
+	}
+	var tags []tag
+	for part := range strings.SplitSeq(header, ",") {
+		code, params, _ := strings.Cut(part, ";")
+		t := tag{code: strings.TrimSpace(code), q: 1}
+		for param := range strings.SplitSeq(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					t.q = q
+				}
+			}
+		}
+		if t.code != "" && t.code != "*" && t.q > 0 {
+			tags = append(tags, t)
+		}
+	}
+	slices.SortStableFunc(tags, func(a, b tag) int { return cmp.Compare(b.q, a.q) })
+
+	for _, t := range tags {
+		base, _, _ := strings.Cut(t.code, "-")
+		for _, code := range []string{t.code, base} {
+			if i := slices.IndexFunc(langs, func(l string) bool { return strings.EqualFold(l, code) }); i >= 0 {
+				return langs[i]
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testTranslations = map[string]map[string]string{
+	"en": {"hello": "Hello", "timeAgo.past": "%s ago", "timeAgo.minutes": "%d minutes", "duration.s": "%ds"},
+	"de": {"hello": "Hallo", "timeAgo.past": "vor %s", "timeAgo.minutes": "%d Minuten", "duration.s": "%d Sek."},
+}
+
+func TestRequestLangCookie(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{t "hello"}}`,
+	}, WithI18n("en", testTranslations), WithLangCookie("lang"))
+
+	tests := []struct {
+		cookie, accept, want string
+	}{
+		{"de", "", "Hallo"},
+		{"", "de-AT, en;q=0.5", "Hallo"},
+		{"xx", "", "Hello"},
+		{"", "", "Hello"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tt.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+		}
+		if tt.accept != "" {
+			r.Header.Set("Accept-Language", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		if err := h.RenderHTTP(w, r, "page.html", nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("cookie %q, Accept-Language %q: got %q, want %q", tt.cookie, tt.accept, got, tt.want)
+		}
+	}
+	if got := h.config.I18n.CurrentLanguage(); got != "en" {
+		t.Fatalf("current language changed to %q", got)
+	}
+}
+
+func TestContextWithLangHumanize(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"ago.html": `{{timeAgo .At}} {{humanDuration .D}}`,
+	}, WithI18n("en", testTranslations))
+	data := map[string]any{"At": time.Now().Add(-5*time.Minute - time.Second), "D": 3}
+
+	var buf bytes.Buffer
+	if err := h.RenderContext(ContextWithLang(context.Background(), "de"), &buf, "ago.html", data); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "vor 5 Minuten 3 Sek." {
+		t.Fatalf("got %q", got)
+	}
+	if got := renderString(t, h, "ago.html", data); got != "5 minutes ago 3s" {
+		t.Fatalf("default language got %q", got)
+	}
+}
+
+func TestSetLangIsPerRender(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":  `{{t "hello"}} {{setLang "de"}}{{t "hello"}} {{currentLang}}`,
+		"other.html": `{{t "hello"}}`,
+	}, WithI18n("en", testTranslations))
+
+	if got := renderString(t, h, "page.html", nil); got != "Hello Hallo de" {
+		t.Fatalf("got %q", got)
+	}
+	if got := renderString(t, h, "other.html", nil); got != "Hello" {
+		t.Fatalf("setLang leaked into the next render: %q", got)
+	}
+	if got := h.config.I18n.CurrentLanguage(); got != "en" {
+		t.Fatalf("current language changed to %q", got)
+	}
+}
+
+func TestRenderHTTPWithLayoutFragmentLang(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":         `{{t "hello"}}`,
+		"layouts/base.html": `<main>{{template "content" .}}</main>`,
+	}, WithI18n("en", testTranslations), WithLayoutDir("layouts"), WithDefaultLayout("base"))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	if err := h.RenderHTTPWithLayout(w, r, &RenderData{View: "page.html", Lang: "de"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != "Hallo" {
+		t.Fatalf("fragment got %q, want the view alone in de", got)
+	}
+}
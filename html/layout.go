@@ -0,0 +1,143 @@
+package html
+
+import (
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template/parse"
+)
+
+// parseLayouts parses the files in the layout directory, each as a template
+// named after its file without the extension, and returns those names.
+func (c *Config) parseLayouts(t *template.Template) (*template.Template, []string, error) {
+	if c.LayoutDir == "" {
+		return t, nil, nil
+	}
+
+	files, err := c.glob(filepath.Join(c.TemplateDir, c.LayoutDir, "*"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var layouts []string
+	for _, file := range files {
+		if info, err := c.stat(file); err != nil || info.IsDir() {
+			continue
+		}
+
+		src, err := c.readFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !c.tagged(c.fileTags(src)) {
+			continue
+		}
+
+		base := filepath.Base(file)
+		name := strings.TrimSuffix(base, filepath.Ext(base))
+		if _, err := t.New(name).Parse(string(src)); err != nil {
+			return nil, nil, fmt.Errorf("layout %s: %w", file, err)
+		}
+		layouts = append(layouts, name)
+	}
+
+	return t, layouts, nil
+}
+
+// IsLayout reports whether name was loaded from the layout directory
+func (h *HTMLTemplate) IsLayout(name string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return slices.Contains(h.layouts, name)
+}
+
+// LayoutNames returns the names of the templates loaded from the layout
+// directory
+func (h *HTMLTemplate) LayoutNames() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return slices.Clone(h.layouts)
+}
+
+// ViewNames returns the names of all templates that are not layouts
+func (h *HTMLTemplate) ViewNames() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var names []string
+	for _, t := range h.t.Templates() {
+		if t.Name() == "" || slices.Contains(h.layouts, t.Name()) {
+			continue
+		}
+		names = append(names, t.Name())
+	}
+	return names
+}
+
+// fileBlocks returns the template named block, such as "content", defined
+// by each file matching pattern, keyed by view name. html/template keeps
+// one template of a name per set, so when several views define one, or a
+// layout has a default, only the last survives parsing; the files are read
+// again to recover each view's own. Names referenced by the blocks get
+// NamePrefix like the rest of t.
+func (c *Config) fileBlocks(t *template.Template, pattern, block string) map[string]*parse.Tree {
+	files, err := c.glob(filepath.Join(c.TemplateDir, pattern))
+	if err != nil {
+		return nil
+	}
+
+	blocks := map[string]*parse.Tree{}
+	for _, file := range files {
+		src, err := c.readFile(file)
+		if err != nil || !strings.Contains(string(src), `"`+block+`"`) || !c.tagged(c.fileTags(src)) {
+			continue
+		}
+
+		name := filepath.Base(file)
+		tree := parse.New(name)
+		tree.Mode = parse.SkipFuncCheck
+		trees := map[string]*parse.Tree{}
+		if _, err := tree.Parse(string(src), c.Delimiters[0], c.Delimiters[1], trees); err != nil {
+			continue
+		}
+
+		own, ok := trees[block]
+		if !ok {
+			continue
+		}
+		if c.NamePrefix != "" {
+			walkTree(own.Root, func(node parse.Node) bool {
+				if n, ok := node.(*parse.TemplateNode); ok && t.Lookup(c.NamePrefix+n.Name) != nil {
+					n.Name = c.NamePrefix + n.Name
+				}
+				return true
+			})
+		}
+		blocks[c.NamePrefix+name] = own
+	}
+	return blocks
+}
+
+// LayoutRule gives the views whose names match Pattern, a path.Match glob
+// such as "admin/*", their own default layout
+type LayoutRule struct {
+	Pattern string
+	Layout  string
+}
+
+// layoutFor returns the default layout for view: that of the first layout
+// rule matching its name or file, or DefaultLayout when none matches
+func (h *HTMLTemplate) layoutFor(view string) string {
+	if len(h.config.LayoutRules) == 0 {
+		return h.config.DefaultLayout
+	}
+	file := h.templateFile(view)
+	for _, rule := range h.config.LayoutRules {
+		if matchTemplate(rule.Pattern, view, file) {
+			return rule.Layout
+		}
+	}
+	return h.config.DefaultLayout
+}
@@ -0,0 +1,195 @@
+package html
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var layoutFiles = map[string]string{
+	"page.html":          `page`,
+	"admin-users.html":   `users`,
+	"layouts/base.html":  `<main>{{template "content" .}}</main>`,
+	"layouts/admin.html": `<admin>{{template "content" .}}</admin>`,
+}
+
+func TestLayoutByDefault(t *testing.T) {
+	h := newTestEngine(t, layoutFiles,
+		WithLayoutDir("layouts"), WithDefaultLayout("base"), WithLayoutByDefault(true))
+
+	if got := renderString(t, h, "page.html", nil); got != "<main>page</main>" {
+		t.Fatalf("Render got %q", got)
+	}
+
+	var buf bytes.Buffer
+	if err := h.RenderNoLayout(&buf, "page.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "page" {
+		t.Fatalf("RenderNoLayout got %q", buf.String())
+	}
+}
+
+func TestLayoutByDefaultOff(t *testing.T) {
+	h := newTestEngine(t, layoutFiles, WithLayoutDir("layouts"), WithDefaultLayout("base"))
+	if got := renderString(t, h, "page.html", nil); got != "page" {
+		t.Fatalf("got %q, want the view alone", got)
+	}
+}
+
+func TestLayoutByDefaultHTTP(t *testing.T) {
+	h := newTestEngine(t, layoutFiles,
+		WithLayoutDir("layouts"), WithDefaultLayout("base"), WithLayoutByDefault(true))
+
+	w := httptest.NewRecorder()
+	if err := h.RenderHTTP(w, httptest.NewRequest("GET", "/", nil), "page.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != "<main>page</main>" {
+		t.Fatalf("RenderHTTP got %q", got)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("HX-Request", "true")
+	w = httptest.NewRecorder()
+	if err := h.RenderHTTP(w, r, "page.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != "page" {
+		t.Fatalf("fragment got %q, want the view alone", got)
+	}
+}
+
+func TestLayoutRules(t *testing.T) {
+	h := newTestEngine(t, layoutFiles,
+		WithLayoutDir("layouts"), WithDefaultLayout("base"), WithLayoutByDefault(true),
+		WithLayoutRules([]LayoutRule{{Pattern: "admin-*", Layout: "admin"}}))
+
+	if got := renderString(t, h, "admin-users.html", nil); got != "<admin>users</admin>" {
+		t.Fatalf("got %q", got)
+	}
+
+	var buf bytes.Buffer
+	if err := h.RenderWithLayout(&buf, &RenderData{View: "page.html", Layout: "admin"}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "<admin>page</admin>" {
+		t.Fatalf("explicit layout got %q", buf.String())
+	}
+}
+
+func TestLayoutRulesOrder(t *testing.T) {
+	files := map[string]string{
+		"admin-home.html":    `admin`,
+		"public-home.html":   `public`,
+		"other.html":         `other`,
+		"layouts/base.html":  `<main>{{template "content" .}}</main>`,
+		"layouts/admin.html": `<admin>{{template "content" .}}</admin>`,
+		"layouts/site.html":  `<site>{{template "content" .}}</site>`,
+	}
+	h := newTestEngine(t, files, WithLayoutDir("layouts"), WithDefaultLayout("base"),
+		WithLayoutRules([]LayoutRule{
+			{Pattern: "admin-*", Layout: "admin"},
+			{Pattern: "*-home.html", Layout: "site"},
+		}))
+
+	tests := map[string]string{
+		"admin-home.html":  "<admin>admin</admin>",
+		"public-home.html": "<site>public</site>",
+		"other.html":       "<main>other</main>",
+	}
+	for view, want := range tests {
+		var buf bytes.Buffer
+		if err := h.RenderWithLayout(&buf, &RenderData{View: view}); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want {
+			t.Errorf("%s: got %q, want %q", view, buf.String(), want)
+		}
+	}
+}
+
+func TestLayoutDir(t *testing.T) {
+	h := newTestEngine(t, layoutFiles, WithLayoutDir("layouts"))
+
+	if got := h.LayoutNames(); !slices.Equal(slices.Sorted(slices.Values(got)), []string{"admin", "base"}) {
+		t.Fatalf("LayoutNames() = %q", got)
+	}
+	if !h.IsLayout("base") || h.IsLayout("page.html") {
+		t.Fatal("IsLayout mixes up layouts and views")
+	}
+	views := h.ViewNames()
+	if !slices.Contains(views, "page.html") || slices.Contains(views, "base") {
+		t.Fatalf("ViewNames() = %q", views)
+	}
+
+	var buf bytes.Buffer
+	if err := h.RenderWithLayout(&buf, &RenderData{View: "page.html", Layout: "base"}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "<main>page</main>" {
+		t.Fatalf("got %q", buf.String())
+	}
+}
+
+func TestLayoutDirMissingDefault(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, layoutFiles)
+	_, err := Sparkle("*.html", WithTemplateDir(dir), WithLayoutDir("layouts"), WithDefaultLayout("main")).CreateEngine()
+	if err == nil || !strings.Contains(err.Error(), "default layout main not found in layouts") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestViewContentBlock(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"own.html":          `ignored{{define "content"}}own {{.}}{{end}}`,
+		"plain.html":        `plain {{.}}`,
+		"layouts/base.html": `<main>{{block "content" .}}default{{end}}</main>`,
+	}, WithLayoutDir("layouts"))
+
+	for view, want := range map[string]string{"own.html": "<main>own x</main>", "plain.html": "<main>plain x</main>"} {
+		var buf bytes.Buffer
+		if err := h.RenderWithLayout(&buf, &RenderData{Layout: "base", View: view, Data: "x"}); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want {
+			t.Errorf("%s got %q, want %q", view, buf.String(), want)
+		}
+	}
+}
+
+func TestLayoutRulesByFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"admin/users.html":    `users`,
+		"public/home.html":    `home`,
+		"layouts/admin.html":  `<admin>{{template "content" .}}</admin>`,
+		"layouts/public.html": `<public>{{template "content" .}}</public>`,
+	})
+	engine, err := Sparkle("*/*.html", WithTemplateDir(dir), WithLayoutDir("layouts"),
+		WithLayoutRules([]LayoutRule{
+			{Pattern: "admin/*", Layout: "admin"},
+			{Pattern: "public/*", Layout: "public"},
+		})).CreateEngine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := engine.(*HTMLTemplate)
+
+	tests := map[string]string{
+		"users.html": "<admin>users</admin>",
+		"home.html":  "<public>home</public>",
+	}
+	for view, want := range tests {
+		var buf bytes.Buffer
+		if err := h.RenderWithLayout(&buf, &RenderData{View: view}); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want {
+			t.Errorf("%s: got %q, want %q", view, buf.String(), want)
+		}
+	}
+}
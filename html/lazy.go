@@ -0,0 +1,48 @@
+package html
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+)
+
+// lazyPartial is a partial deferred until the main body has rendered
+type lazyPartial struct {
+	token string
+	name  string
+	data  []any
+}
+
+// lazy renders the named template after the rest of the page, returning a
+// <div data-lazy> placeholder and appending the output in a matching
+// <template data-lazy-fill> for a script to move into place.
+func (rs *renderState) lazy(name string, data ...any) (template.HTML, error) {
+	name = rs.relative(name)
+	rs.lazyN++
+	token := fmt.Sprintf("lazy-%d", rs.lazyN)
+	rs.lazies = append(rs.lazies, lazyPartial{token: token, name: name, data: data})
+	return template.HTML(`<div data-lazy="` + token + `"></div>`), nil
+}
+
+// flushLazy renders the deferred templates after the page, including any
+// they defer in turn
+func (rs *renderState) flushLazy() error {
+	if f, ok := rs.w.(http.Flusher); ok && rs.stream && len(rs.lazies) > 0 {
+		f.Flush()
+	}
+
+	for len(rs.lazies) > 0 {
+		l := rs.lazies[0]
+		rs.lazies = rs.lazies[1:]
+
+		out, err := rs.partial(l.name, l.data...)
+		if err != nil {
+			return fmt.Errorf("lazy %s: %w", l.name, err)
+		}
+		if _, err := io.WriteString(rs.w, `<template data-lazy-fill="`+l.token+`">`+string(out)+`</template>`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package html
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":     `<main>{{lazy "recs.html" .}}<p>body</p></main>`,
+		"recs.html":     `<ul>{{range .}}<li>{{.}}</li>{{end}}</ul>{{lazy "comments.html"}}`,
+		"comments.html": `<p>comments</p>`,
+		"broken.html":   `{{lazy "missing.html"}}`,
+	})
+
+	got := renderString(t, h, "page.html", []string{"a"})
+	want := `<main><div data-lazy="lazy-1"></div><p>body</p></main>` +
+		`<template data-lazy-fill="lazy-1"><ul><li>a</li></ul><div data-lazy="lazy-2"></div></template>` +
+		`<template data-lazy-fill="lazy-2"><p>comments</p></template>`
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	if err := h.Render(&strings.Builder{}, "broken.html", nil); err == nil || !strings.Contains(err.Error(), "lazy missing.html") {
+		t.Fatalf("missing lazy template got %v", err)
+	}
+}
+
+func TestLazyStreamFlushesBody(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `<p>body</p>{{lazy "late.html"}}`,
+		"late.html": `late`,
+	})
+
+	var w flushRecorder
+	if err := h.RenderStream(&w, "page.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.flushed) == 0 || w.flushed[0] != `<p>body</p><div data-lazy="lazy-1"></div>` {
+		t.Fatalf("flushed %q", w.flushed)
+	}
+	if !strings.HasSuffix(w.String(), `<template data-lazy-fill="lazy-1">late</template>`) {
+		t.Fatalf("got %q", w.String())
+	}
+}
@@ -0,0 +1,74 @@
+package html
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// LintRender trial-renders name with data and returns every undefined
+// template, missing key, helper error and panic it finds, for CI checks.
+func (h *HTMLTemplate) LintRender(name string, data any) []error {
+	name = h.resolve(name)
+	if err := h.validateTemplate(name); err != nil {
+		return []error{err}
+	}
+
+	h.mu.RLock()
+	base, deps := h.base, h.deps
+	h.mu.RUnlock()
+
+	t, err := base.Clone()
+	if err != nil {
+		return []error{err}
+	}
+	t.Option("missingkey=error")
+
+	var errs []error
+	var missing []string
+	optional := optionalIncludes(t)
+	for _, from := range reachable(deps, name) {
+		for _, ref := range deps[from] {
+			if ref == "content" || optional[ref] || t.Lookup(ref) != nil {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("template %s references undefined template %q", from, ref))
+			missing = append(missing, ref)
+		}
+	}
+	slices.Sort(missing)
+	for _, ref := range slices.Compact(missing) {
+		if _, err := t.New(ref).Parse(" "); err != nil {
+			return append(errs, err)
+		}
+	}
+
+	rs := &renderState{h: h, t: t, w: io.Discard, name: name, strict: true}
+	t.Funcs(rs.funcs())
+
+	data, err = h.config.transform(name, data)
+	if err != nil {
+		return append(errs, err)
+	}
+	if data, err = h.config.prepare(name, data); err != nil {
+		return append(errs, err)
+	}
+
+	if err := rs.preload(context.Background(), name); err != nil {
+		return append(errs, err)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errs = append(errs, fmt.Errorf("template %s panicked: %v", name, r))
+			}
+		}()
+		if err := t.ExecuteTemplate(io.Discard, name, data); err != nil {
+			errs = append(errs, err)
+		}
+	}()
+
+	return append(errs, rs.errs...)
+}
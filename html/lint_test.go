@@ -0,0 +1,61 @@
+package html
+
+import (
+	"errors"
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestLintRender(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{template "ghost.html"}}{{includeIfExists "opt.html"}}{{check false}}{{check false}}{{.Missing}}`,
+		"ok.html":   `{{.Name}}`,
+	}, WithFuncs(template.FuncMap{
+		"check": func(ok bool) (string, error) {
+			if !ok {
+				return "", errors.New("failed")
+			}
+			return "", nil
+		},
+	}))
+
+	errs := h.LintRender("page.html", map[string]any{})
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	all := strings.Join(msgs, "\n")
+	for _, want := range []string{
+		`template page.html references undefined template "ghost.html"`,
+		"function check in template page.html: failed",
+		`map has no entry for key "Missing"`,
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("errors lack %q:\n%s", want, all)
+		}
+	}
+	if strings.Contains(all, "opt.html") {
+		t.Errorf("optional include reported:\n%s", all)
+	}
+	if n := strings.Count(all, "function check"); n != 2 {
+		t.Errorf("%d helper errors, want 2", n)
+	}
+
+	if errs := h.LintRender("ok.html", map[string]any{"Name": "x"}); len(errs) != 0 {
+		t.Fatalf("clean template got %v", errs)
+	}
+	// The trial render leaves the engine's set untouched
+	if h.HasTemplate("ghost.html") {
+		t.Fatal("stub leaked into the engine")
+	}
+}
+
+func TestLintRenderPanic(t *testing.T) {
+	h := newTestEngine(t, map[string]string{"page.html": `{{boom}}`},
+		WithFuncs(template.FuncMap{"boom": func() string { panic("kaboom") }}))
+	errs := h.LintRender("page.html", nil)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "kaboom") {
+		t.Fatalf("got %v", errs)
+	}
+}
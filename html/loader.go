@@ -0,0 +1,129 @@
+package html
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"slices"
+	"text/template/parse"
+)
+
+// loadKeys maps each template in t to the data loader keys it requests
+// with {{load "key"}}
+func loadKeys(t *template.Template) map[string][]string {
+	keys := map[string][]string{}
+	for _, tpl := range t.Templates() {
+		if tpl.Tree == nil {
+			continue
+		}
+		walkTree(tpl.Tree.Root, func(node parse.Node) bool {
+			n, ok := node.(*parse.CommandNode)
+			if !ok || len(n.Args) != 2 {
+				return true
+			}
+			if fn, ok := n.Args[0].(*parse.IdentifierNode); ok && fn.Ident == "load" {
+				if key, ok := n.Args[1].(*parse.StringNode); ok {
+					keys[tpl.Name()] = append(keys[tpl.Name()], key.Text)
+				}
+			}
+			return true
+		})
+	}
+	return keys
+}
+
+// preload runs the data loaders requested by the named templates and the
+// templates they include, and keeps the results for load
+func (rs *renderState) preload(ctx context.Context, names ...string) error {
+	rs.ctx = ctx
+	h := rs.h
+	if len(h.config.Loaders) == 0 {
+		return nil
+	}
+
+	h.mu.RLock()
+	deps, loads := h.deps, h.loads
+	h.mu.RUnlock()
+
+	var keys []string
+	for _, name := range reachable(deps, names...) {
+		keys = append(keys, loads[name]...)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	slices.Sort(keys)
+
+	values, err := h.config.runLoaders(ctx, slices.Compact(keys))
+	if err != nil {
+		return err
+	}
+	rs.loaded = values
+	return nil
+}
+
+// load returns the value of a data loader. Keys that were not preloaded,
+// because the template computes them, are loaded on demand.
+func (rs *renderState) load(key string) (any, error) {
+	if v, ok := rs.loaded[key]; ok {
+		return v, nil
+	}
+
+	ctx := rs.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	values, err := rs.h.config.runLoaders(ctx, []string{key})
+	if err != nil {
+		return nil, err
+	}
+	if rs.loaded == nil {
+		rs.loaded = map[string]any{}
+	}
+	rs.loaded[key] = values[key]
+	return values[key], nil
+}
+
+// runLoaders runs the loaders for keys concurrently. They share a deadline
+// of LoaderTimeout; the first error, or the deadline passing, fails the
+// whole batch without waiting for loaders that ignore their context.
+func (c *Config) runLoaders(ctx context.Context, keys []string) (map[string]any, error) {
+	if c.LoaderTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.LoaderTimeout)
+		defer cancel()
+	}
+
+	type result struct {
+		key   string
+		value any
+		err   error
+	}
+
+	results := make(chan result, len(keys))
+	for _, key := range keys {
+		loader, ok := c.Loaders[key]
+		if !ok {
+			return nil, fmt.Errorf("load %s: no data loader registered", key)
+		}
+		go func() {
+			v, err := loader(ctx)
+			results <- result{key, v, err}
+		}()
+	}
+
+	values := make(map[string]any, len(keys))
+	for range keys {
+		select {
+		case r := <-results:
+			if r.err != nil {
+				return nil, fmt.Errorf("load %s: %w", r.key, r.err)
+			}
+			values[r.key] = r.value
+		case <-ctx.Done():
+			return nil, fmt.Errorf("load: %w", ctx.Err())
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,69 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+func TestDataLoaders(t *testing.T) {
+	// Each loader waits for the other, so they only finish when run
+	// concurrently
+	var started sync.WaitGroup
+	started.Add(2)
+	rendezvous := func(v string) func(context.Context) (any, error) {
+		return func(ctx context.Context) (any, error) {
+			started.Done()
+			started.Wait()
+			return v + ctx.Value(ctxKey{}).(string), nil
+		}
+	}
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{load "user"}} {{partial "side.html"}}`,
+		"side.html": `{{load "news"}}`,
+	}, WithDataLoaders(map[string]func(context.Context) (any, error){
+		"user": rendezvous("ann"),
+		"news": rendezvous("today"),
+	}), WithLoaderTimeout(5*time.Second))
+
+	var buf bytes.Buffer
+	ctx := context.WithValue(context.Background(), ctxKey{}, "!")
+	if err := h.RenderContext(ctx, &buf, "page.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "ann! today!" {
+		t.Fatalf("got %q", buf.String())
+	}
+}
+
+func TestDataLoaderErrors(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"slow.html":    `{{load "slow"}}`,
+		"fail.html":    `{{load "fail"}}`,
+		"unknown.html": `{{load "nope"}}`,
+	}, WithDataLoaders(map[string]func(context.Context) (any, error){
+		"slow": func(ctx context.Context) (any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+		"fail": func(context.Context) (any, error) { return nil, errors.New("db down") },
+	}), WithLoaderTimeout(10*time.Millisecond))
+
+	tests := map[string]string{
+		"slow.html":    "deadline exceeded",
+		"fail.html":    "load fail: db down",
+		"unknown.html": "load nope: no data loader registered",
+	}
+	for name, want := range tests {
+		var buf bytes.Buffer
+		if err := h.Render(&buf, name, nil); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got %v, want %q", name, err, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package html
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LocaleFormat describes how a language writes numbers, money and dates
+type LocaleFormat struct {
+	Decimal    string // decimal mark
+	Thousands  string // digit group separator
+	DateLayout string // layout of formatDate when none is given
+	Currency   string // money pattern with {symbol} and {amount}
+}
+
+// localeFormats are the built-in formats, keyed by language code. Region
+// codes fall back to their language, "de-AT" to "de".
+var localeFormats = map[string]LocaleFormat{
+	"en":    {Decimal: ".", Thousands: ",", DateLayout: "Jan 2, 2006", Currency: "{symbol}{amount}"},
+	"en-GB": {Decimal: ".", Thousands: ",", DateLayout: "2 Jan 2006", Currency: "{symbol}{amount}"},
+	"de":    {Decimal: ",", Thousands: ".", DateLayout: "02.01.2006", Currency: "{amount} {symbol}"},
+	"fr":    {Decimal: ",", Thousands: " ", DateLayout: "02/01/2006", Currency: "{amount} {symbol}"},
+	"es":    {Decimal: ",", Thousands: ".", DateLayout: "02/01/2006", Currency: "{amount} {symbol}"},
+	"it":    {Decimal: ",", Thousands: ".", DateLayout: "02/01/2006", Currency: "{amount} {symbol}"},
+	"nl":    {Decimal: ",", Thousands: ".", DateLayout: "02-01-2006", Currency: "{symbol} {amount}"},
+	"pt":    {Decimal: ",", Thousands: ".", DateLayout: "02/01/2006", Currency: "{symbol} {amount}"},
+	"ja":    {Decimal: ".", Thousands: ",", DateLayout: "2006/01/02", Currency: "{symbol}{amount}"},
+	"zh":    {Decimal: ".", Thousands: ",", DateLayout: "2006/01/02", Currency: "{symbol}{amount}"},
+	"id":    {Decimal: ",", Thousands: ".", DateLayout: "02/01/2006", Currency: "{symbol}{amount}"},
+}
+
+// defaultLocale is used for languages without a format
+const defaultLocale = "en"
+
+// localeFormat returns the format of lang, or of the current language when
+// lang is empty: the one set in I18nConfig.LocaleFormats, else the
+// built-in one, trying the language without its region before falling
+// back to English
+func (c *Config) localeFormat(lang string) LocaleFormat {
+	var custom map[string]LocaleFormat
+	if c.I18n != nil {
+		c.I18n.mu.RLock()
+		if lang == "" {
+			lang = c.I18n.lang()
+		}
+		custom = c.I18n.LocaleFormats
+		c.I18n.mu.RUnlock()
+	}
+	if lang == "" {
+		lang = defaultLocale
+	}
+
+	base, _, _ := strings.Cut(lang, "-")
+	for _, code := range []string{lang, base} {
+		if f, ok := custom[code]; ok {
+			return f
+		}
+		if f, ok := localeFormats[code]; ok {
+			return f
+		}
+	}
+	return localeFormats[defaultLocale]
+}
+
+// dateLayout returns layout, or the date layout of lang when it is empty
+func (c *Config) dateLayout(lang, layout string) string {
+	if layout != "" {
+		return layout
+	}
+	return c.localeFormat(lang).DateLayout
+}
+
+// formatNumber formats a number with the separators of the current
+// language, 1234567.891 -> "1,234,567.89" in English and "1.234.567,89" in
+// German. Integers get no decimals and floats two unless decimals is given.
+func (c *Config) formatNumber(v any, decimals ...int) (string, error) {
+	return c.formatNumberIn("", v, decimals...)
+}
+
+// formatNumberIn is formatNumber in lang, or the current language when
+// lang is empty
+func (c *Config) formatNumberIn(lang string, v any, decimals ...int) (string, error) {
+	f, err := toFloat64(v)
+	if err != nil {
+		return "", fmt.Errorf("formatNumber: %w", err)
+	}
+
+	prec := 2
+	if _, isInt := toInt64(v); isInt {
+		prec = 0
+	}
+	if len(decimals) > 0 {
+		prec = decimals[0]
+	}
+	return localizeNumber(f, prec, c.localeFormat(lang)), nil
+}
+
+// formatCurrency formats an amount with two decimals and places symbol as
+// the current language does, {{formatCurrency .Total "€"}} -> "€1,234.50"
+// in English and "1.234,50 €" in German
+func (c *Config) formatCurrency(v any, symbol string) (string, error) {
+	return c.formatCurrencyIn("", v, symbol)
+}
+
+// formatCurrencyIn is formatCurrency in lang, or the current language when
+// lang is empty
+func (c *Config) formatCurrencyIn(lang string, v any, symbol string) (string, error) {
+	f, err := toFloat64(v)
+	if err != nil {
+		return "", fmt.Errorf("formatCurrency: %w", err)
+	}
+
+	format := c.localeFormat(lang)
+	amount := localizeNumber(f, 2, format)
+	sign := ""
+	if strings.HasPrefix(amount, "-") {
+		sign, amount = "-", amount[1:]
+	}
+	return sign + strings.NewReplacer("{symbol}", symbol, "{amount}", amount).Replace(format.Currency), nil
+}
+
+// localizeNumber formats f with prec decimals and the separators of format
+func localizeNumber(f float64, prec int, format LocaleFormat) string {
+	s := strconv.FormatFloat(f, 'f', prec, 64)
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	for i, d := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(format.Thousands)
+		}
+		b.WriteRune(d)
+	}
+	if frac != "" {
+		b.WriteString(format.Decimal + frac)
+	}
+	return sign + b.String()
+}
@@ -0,0 +1,36 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLocaleFormats(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{formatNumber .N}} {{formatNumber 1500}} {{formatCurrency .N "€"}} {{formatDate "" .D}}`,
+	}, WithI18n("en", testTranslations), WithLocaleFormats(map[string]LocaleFormat{
+		"fr": {Decimal: ".", Thousands: "_", DateLayout: "2006-01-02", Currency: "{amount}{symbol}"},
+	}))
+	data := map[string]any{"N": -1234567.891, "D": time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		lang, want string
+	}{
+		{"en", "-1,234,567.89 1,500 -€1,234,567.89 Mar 9, 2024"},
+		{"de", "-1.234.567,89 1.500 -1.234.567,89 € 09.03.2024"},
+		{"de-AT", "-1.234.567,89 1.500 -1.234.567,89 € 09.03.2024"},
+		{"fr", "-1_234_567.89 1_500 -1_234_567.89€ 2024-03-09"},
+		{"xx", "-1,234,567.89 1,500 -€1,234,567.89 Mar 9, 2024"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := h.RenderContext(ContextWithLang(context.Background(), tt.lang), &buf, "page.html", data); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+}
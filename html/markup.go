@@ -0,0 +1,89 @@
+package html
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// optionalEndElements may be closed implicitly by their parent's end tag
+// or the end of the document
+var optionalEndElements = []string{
+	"body", "colgroup", "dd", "dt", "head", "html", "li", "optgroup",
+	"option", "p", "rb", "rp", "rt", "rtc", "tbody", "td", "tfoot", "th",
+	"thead", "tr",
+}
+
+// checkOutput returns w teed into a buffer and a function that logs the
+// markup and AMP problems found in what was written, when output or AMP
+// checks are on in development mode. Otherwise w is returned as is and the
+// function does nothing.
+func (h *HTMLTemplate) checkOutput(name string, w io.Writer) (io.Writer, func()) {
+	if !h.config.Development || !h.config.CheckOutput && !h.config.CheckAMP {
+		return w, func() {}
+	}
+
+	var buf bytes.Buffer
+	return io.MultiWriter(w, &buf), func() {
+		if h.config.CheckOutput {
+			for _, problem := range markupProblems(buf.Bytes()) {
+				log.Printf("Template %s output: %s", name, problem)
+			}
+		}
+		if h.config.CheckAMP {
+			for _, problem := range ampProblems(buf.Bytes()) {
+				log.Printf("Template %s AMP: %s", name, problem)
+			}
+		}
+	}
+}
+
+// markupProblems returns the unbalanced and misnested tags in src. End
+// tags the HTML spec lets authors omit, such as </li> and </p>, are not
+// required.
+func markupProblems(src []byte) []string {
+	var problems []string
+	var open []string
+
+	z := xhtml.NewTokenizer(bytes.NewReader(src))
+	for {
+		tt := z.Next()
+		switch tt {
+		case xhtml.ErrorToken:
+			for _, tag := range slices.Backward(open) {
+				if !slices.Contains(optionalEndElements, tag) {
+					problems = append(problems, fmt.Sprintf("unclosed <%s>", tag))
+				}
+			}
+			return problems
+
+		case xhtml.StartTagToken:
+			tag, _ := z.TagName()
+			if !isVoidElement(string(tag)) {
+				open = append(open, string(tag))
+			}
+
+		case xhtml.EndTagToken:
+			raw, _ := z.TagName()
+			tag := string(raw)
+			i := len(open) - 1
+			for i >= 0 && open[i] != tag {
+				i--
+			}
+			if i < 0 {
+				problems = append(problems, fmt.Sprintf("unexpected </%s>", tag))
+				continue
+			}
+			for _, inner := range open[i+1:] {
+				if !slices.Contains(optionalEndElements, inner) {
+					problems = append(problems, fmt.Sprintf("<%s> not closed before </%s>", inner, tag))
+				}
+			}
+			open = open[:i]
+		}
+	}
+}
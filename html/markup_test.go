@@ -0,0 +1,40 @@
+package html
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMarkupProblems(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{`<div><p>text<br><img src="a.png"></div>`, nil},
+		{`<ul><li>one<li>two</ul>`, nil},
+		{`<div><span>text</div>`, []string{"<span> not closed before </div>"}},
+		{`<div>text`, []string{"unclosed <div>"}},
+		{`text</section>`, []string{"unexpected </section>"}},
+		{`<main><input><hr></main>`, nil},
+	}
+	for _, tt := range tests {
+		if got := markupProblems([]byte(tt.src)); !slices.Equal(got, tt.want) {
+			t.Errorf("markupProblems(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestCheckOutputLogs(t *testing.T) {
+	logs := captureLogs(t)
+	h := newTestEngine(t, map[string]string{
+		"page.html": `<div><span>{{.}}</div>`,
+	}, WithDevelopment(true), WithOutputCheck(true))
+
+	if got := renderString(t, h, "page.html", "x"); got != "<div><span>x</div>" {
+		t.Fatalf("got %q, want the output untouched", got)
+	}
+	if !strings.Contains(logs.String(), "Template page.html output: <span> not closed before </div>") {
+		t.Fatalf("logs %q", logs.String())
+	}
+}
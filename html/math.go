@@ -0,0 +1,150 @@
+package html
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"html/template"
+	"math"
+	"reflect"
+)
+
+// toFloats converts both operands of the named helper to float64
+func toFloats(name string, a, b any) (x, y float64, err error) {
+	if x, err = toFloat64(a); err != nil {
+		return x, y, fmt.Errorf("%s: %w", name, err)
+	}
+	if y, err = toFloat64(b); err != nil {
+		return x, y, fmt.Errorf("%s: %w", name, err)
+	}
+	return x, y, nil
+}
+
+// arith returns a binary arithmetic helper. Two integers of any size give
+// an int64 result; if either operand is a float, both are promoted to
+// float64. Integer results that overflow int64 are an error rather than
+// wrapping around.
+func arith(name string, ints func(a, b int64) (int64, error), floats func(a, b float64) (float64, error)) func(a, b any) (any, error) {
+	return func(a, b any) (any, error) {
+		x, xInt := toInt64(a)
+		y, yInt := toInt64(b)
+
+		var r any
+		var err error
+		if xInt && yInt {
+			r, err = ints(x, y)
+		} else {
+			var f, g float64
+			if f, g, err = toFloats(name, a, b); err != nil {
+				return nil, err
+			}
+			r, err = floats(f, g)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return r, nil
+	}
+}
+
+var (
+	errDivByZero = errors.New("division by zero")
+	errOverflow  = errors.New("integer overflow")
+)
+
+// mathFuncs are the arithmetic helpers. div and mod on two integers use
+// integer division like Go, {{div 7 2}} is 3; pass a float for 3.5.
+func mathFuncs() template.FuncMap {
+	return template.FuncMap{
+		"add": arith("add",
+			func(a, b int64) (int64, error) {
+				r := a + b
+				if (b > 0 && r < a) || (b < 0 && r > a) {
+					return 0, errOverflow
+				}
+				return r, nil
+			},
+			func(a, b float64) (float64, error) { return a + b, nil }),
+		"sub": arith("sub",
+			func(a, b int64) (int64, error) {
+				r := a - b
+				if (b > 0 && r > a) || (b < 0 && r < a) {
+					return 0, errOverflow
+				}
+				return r, nil
+			},
+			func(a, b float64) (float64, error) { return a - b, nil }),
+		"mul": arith("mul",
+			func(a, b int64) (int64, error) {
+				r := a * b
+				if a != 0 && (r/a != b || (a == -1 && b == math.MinInt64)) {
+					return 0, errOverflow
+				}
+				return r, nil
+			},
+			func(a, b float64) (float64, error) { return a * b, nil }),
+		"div": arith("div",
+			func(a, b int64) (int64, error) {
+				if b == 0 {
+					return 0, errDivByZero
+				}
+				if a == math.MinInt64 && b == -1 {
+					return 0, errOverflow
+				}
+				return a / b, nil
+			},
+			func(a, b float64) (float64, error) {
+				if b == 0 {
+					return 0, errDivByZero
+				}
+				return a / b, nil
+			}),
+		"mod": arith("mod",
+			func(a, b int64) (int64, error) {
+				if b == 0 {
+					return 0, errDivByZero
+				}
+				return a % b, nil
+			},
+			func(a, b float64) (float64, error) {
+				if b == 0 {
+					return 0, errDivByZero
+				}
+				return math.Mod(a, b), nil
+			}),
+		"gt":  compare("gt", func(c int) bool { return c > 0 }),
+		"lt":  compare("lt", func(c int) bool { return c < 0 }),
+		"gte": compare("gte", func(c int) bool { return c >= 0 }),
+		"lte": compare("lte", func(c int) bool { return c <= 0 }),
+	}
+}
+
+// compare returns a comparison helper. Numbers of any type compare by
+// value, so {{gt .Count 1.5}} works whether Count is an int or a float64
+// decoded from JSON. Two strings, including named string types, compare
+// lexically, as with the predefined gt and lt these replace.
+func compare(name string, ok func(c int) bool) func(a, b any) (bool, error) {
+	return func(a, b any) (bool, error) {
+		if s := reflect.ValueOf(a); s.Kind() == reflect.String {
+			t := reflect.ValueOf(b)
+			if t.Kind() != reflect.String {
+				return false, fmt.Errorf("%s: can't compare %T and %T", name, a, b)
+			}
+			return ok(cmp.Compare(s.String(), t.String())), nil
+		}
+
+		if x, isInt := toInt64(a); isInt {
+			if y, isInt := toInt64(b); isInt {
+				return ok(cmp.Compare(x, y)), nil
+			}
+		}
+		x, y, err := toFloats(name, a, b)
+		if err != nil {
+			return false, err
+		}
+		if math.IsNaN(x) || math.IsNaN(y) {
+			return false, fmt.Errorf("%s: can't compare NaN", name)
+		}
+		return ok(cmp.Compare(x, y)), nil
+	}
+}
@@ -0,0 +1,127 @@
+package html
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+type score int
+
+type label string
+
+func TestArith(t *testing.T) {
+	funcs := mathFuncs()
+	tests := []struct {
+		fn   string
+		a, b any
+		want any
+	}{
+		{"add", 1, 2, int64(3)},
+		{"add", int64(1), int8(2), int64(3)},
+		{"add", 1, 0.5, 1.5},
+		{"add", score(2), 3, int64(5)},
+		{"add", uint64(math.MaxUint64), 1, float64(math.MaxUint64) + 1},
+		{"sub", 1, int64(3), int64(-2)},
+		{"sub", 2.5, 1, 1.5},
+		{"mul", int32(3), int64(4), int64(12)},
+		{"mul", 3, 0.5, 1.5},
+		{"div", 7, 2, int64(3)},
+		{"div", 7, 2.0, 3.5},
+		{"mod", 7, int64(3), int64(1)},
+		{"mod", 7.5, 2, 1.5},
+	}
+	for _, tt := range tests {
+		fn := funcs[tt.fn].(func(a, b any) (any, error))
+		got, err := fn(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("%s %v %v: %v", tt.fn, tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s %v %v = %v (%T), want %v (%T)", tt.fn, tt.a, tt.b, got, got, tt.want, tt.want)
+		}
+	}
+}
+
+func TestArithErrors(t *testing.T) {
+	funcs := mathFuncs()
+	tests := []struct {
+		fn   string
+		a, b any
+		want error
+	}{
+		{"add", int64(math.MaxInt64), 1, errOverflow},
+		{"add", int64(math.MinInt64), -1, errOverflow},
+		{"sub", int64(math.MinInt64), 1, errOverflow},
+		{"sub", int64(math.MaxInt64), -1, errOverflow},
+		{"mul", int64(math.MaxInt64), 2, errOverflow},
+		{"mul", -1, int64(math.MinInt64), errOverflow},
+		{"mul", int64(math.MinInt64), -1, errOverflow},
+		{"div", int64(math.MinInt64), -1, errOverflow},
+		{"div", 1, 0, errDivByZero},
+		{"div", 1.0, 0, errDivByZero},
+		{"mod", 1, 0, errDivByZero},
+	}
+	for _, tt := range tests {
+		fn := funcs[tt.fn].(func(a, b any) (any, error))
+		if _, err := fn(tt.a, tt.b); !errors.Is(err, tt.want) {
+			t.Errorf("%s %v %v: got %v, want %v", tt.fn, tt.a, tt.b, err, tt.want)
+		}
+	}
+
+	add := funcs["add"].(func(a, b any) (any, error))
+	if _, err := add("1", 2); err == nil {
+		t.Error("add of a string: want an error")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	funcs := mathFuncs()
+	tests := []struct {
+		fn   string
+		a, b any
+		want bool
+	}{
+		{"gt", 2, 1, true},
+		{"gt", int64(2), 1.5, true},
+		{"gt", 1.5, int64(2), false},
+		{"lt", int8(-1), uint(0), true},
+		{"gte", 2, 2.0, true},
+		{"lte", score(3), 2, false},
+		{"gt", uint64(math.MaxUint64), int64(math.MaxInt64), true},
+		{"gt", int64(math.MaxInt64), int64(math.MaxInt64 - 1), true},
+		{"lt", "a", "b", true},
+		{"gt", label("b"), "a", true},
+		{"lte", label("a"), label("a"), true},
+	}
+	for _, tt := range tests {
+		fn := funcs[tt.fn].(func(a, b any) (bool, error))
+		got, err := fn(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("%s %v %v: %v", tt.fn, tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s %v %v = %v, want %v", tt.fn, tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	gt := funcs["gt"].(func(a, b any) (bool, error))
+	if _, err := gt("a", 1); err == nil {
+		t.Error("gt of a string and a number: want an error")
+	}
+	if _, err := gt(math.NaN(), 1); err == nil {
+		t.Error("gt of NaN: want an error")
+	}
+}
+
+func TestMathTemplate(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"math.html": `{{add .N 1}} {{div .N 2}} {{if gt .N .Limit}}over{{end}}`,
+	})
+	got := renderString(t, h, "math.html", map[string]any{"N": 5, "Limit": 4.5})
+	if got != "6 2 over" {
+		t.Fatalf("got %q", got)
+	}
+}
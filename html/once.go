@@ -0,0 +1,64 @@
+package html
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// once returns the value cached under key for this render, computing it on
+// the first call. Pass a function to defer the work; it is called with
+// args, and an error it returns is not cached:
+//
+//	{{range .Items}}{{once "stats" $.Stats}}{{end}}
+func (rs *renderState) once(key string, value any, args ...any) (any, error) {
+	if v, ok := rs.onces[key]; ok {
+		return v, nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Func {
+		var err error
+		if value, err = callOnce(v, args); err != nil {
+			return nil, fmt.Errorf("once %s: %w", key, err)
+		}
+	}
+
+	if rs.onces == nil {
+		rs.onces = map[string]any{}
+	}
+	rs.onces[key] = value
+	return value, nil
+}
+
+// callOnce calls fn with args and returns its result
+func callOnce(fn reflect.Value, args []any) (any, error) {
+	t := fn.Type()
+	if t.NumOut() == 0 || t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != errorType) {
+		return nil, fmt.Errorf("function must return a value and an optional error, not %s", t)
+	}
+	if n := t.NumIn(); len(args) != n && !(t.IsVariadic() && len(args) >= n-1) {
+		return nil, fmt.Errorf("function takes %d arguments, got %d", n, len(args))
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		param := t.In(min(i, t.NumIn()-1))
+		if t.IsVariadic() && i >= t.NumIn()-1 {
+			param = param.Elem()
+		}
+		if arg == nil {
+			in[i] = reflect.Zero(param)
+			continue
+		}
+		in[i] = reflect.ValueOf(arg)
+		if !in[i].Type().AssignableTo(param) {
+			return nil, fmt.Errorf("argument %d: %s is not assignable to %s", i, in[i].Type(), param)
+		}
+	}
+
+	out := fn.Call(in)
+	if len(out) == 2 && !out[1].IsNil() {
+		return nil, out[1].Interface().(error)
+	}
+	return out[0].Interface(), nil
+}
@@ -0,0 +1,55 @@
+package html
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOnce(t *testing.T) {
+	calls := 0
+	data := map[string]any{
+		"Items": []int{1, 2, 3},
+		"Stats": func(base int) int { calls++; return base + calls },
+	}
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{range .Items}}{{once "stats" $.Stats 10}} {{end}}{{partial "side.html" .}}`,
+		"side.html": `[{{once "stats" .Stats 20}}]`,
+	})
+
+	if got := renderString(t, h, "page.html", data); got != "11 11 11 [11]" {
+		t.Fatalf("got %q", got)
+	}
+	if calls != 1 {
+		t.Fatalf("function ran %d times, want once", calls)
+	}
+
+	// Each render starts without cached values
+	if got := renderString(t, h, "page.html", data); got != "12 12 12 [12]" {
+		t.Fatalf("second render got %q", got)
+	}
+}
+
+func TestOnceError(t *testing.T) {
+	calls := 0
+	data := map[string]any{
+		"Load": func() (string, error) {
+			calls++
+			return "", errors.New("unavailable")
+		},
+	}
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{once "v" .Load}}`,
+		"args.html": `{{once "v" .Load 1}}`,
+	})
+
+	if err := h.Render(&strings.Builder{}, "page.html", data); err == nil || !strings.Contains(err.Error(), "once v: unavailable") {
+		t.Fatalf("got %v", err)
+	}
+	if err := h.Render(&strings.Builder{}, "args.html", data); err == nil || !strings.Contains(err.Error(), "takes 0 arguments, got 1") {
+		t.Fatalf("got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("function ran %d times", calls)
+	}
+}
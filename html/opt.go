@@ -1,8 +1,13 @@
 package html
 
 import (
+	"context"
 	"html/template"
+	"io/fs"
 	"maps"
+	"net/http"
+	"strings"
+	"time"
 )
 
 // Option is a functional option for configuring the template engine
@@ -22,6 +27,14 @@ func WithTemplateDir(dir string) Option {
 	}
 }
 
+// WithTemplateFS reads templates from layered file systems, later layers
+// overriding files at the same path in earlier ones
+func WithTemplateFS(layers ...fs.FS) Option {
+	return func(c *Config) {
+		c.TemplateFS = layers
+	}
+}
+
 // WithAssetDir sets the asset directory
 func WithAssetDir(dir string) Option {
 	return func(c *Config) {
@@ -29,6 +42,16 @@ func WithAssetDir(dir string) Option {
 	}
 }
 
+// WithAssetHost makes the asset helper produce absolute URLs on host, such
+// as "https://cdn.example.com", for assets served from another domain.
+// The versioned path is appended to the host as is, so
+// "https://cdn.example.com/assets/app.css?v=..." keeps its fingerprint.
+func WithAssetHost(host string) Option {
+	return func(c *Config) {
+		c.AssetHost = host
+	}
+}
+
 // WithFuncs adds custom template functions
 func WithFuncs(funcs template.FuncMap) Option {
 	return func(c *Config) {
@@ -60,6 +83,37 @@ func WithDefaultLayout(layout string) Option {
 	}
 }
 
+// WithLayoutByDefault makes Render and RenderHTTP wrap views in their
+// default layout, the one RenderWithLayout would pick, for apps where every
+// page has one. It is off by default, and they then render templates on
+// their own as before. Layouts themselves are still rendered alone, fragment
+// requests still get the view alone, and RenderNoLayout renders any
+// template without a layout.
+func WithLayoutByDefault(enable bool) Option {
+	return func(c *Config) {
+		c.LayoutByDefault = enable
+	}
+}
+
+// WithLayoutRules gives sections of the app their own default layout, such
+// as "admin" for views matching "admin/*". Rules are tried in order and the
+// first whose pattern matches the view's name, or its file below the
+// template directory, wins; views matching none use the default layout.
+func WithLayoutRules(rules []LayoutRule) Option {
+	return func(c *Config) {
+		c.LayoutRules = rules
+	}
+}
+
+// WithLayoutDir parses every file in the given subdirectory of the
+// template directory as a layout named after the file without its
+// extension, so layouts/base.html can be used as "base"
+func WithLayoutDir(dir string) Option {
+	return func(c *Config) {
+		c.LayoutDir = dir
+	}
+}
+
 // WithCache enables or disables template caching
 func WithCache(enable bool) Option {
 	return func(c *Config) {
@@ -67,13 +121,568 @@ func WithCache(enable bool) Option {
 	}
 }
 
-// WithI18n configures internationalization
+// WithI18n configures internationalization. RenderHTTP and
+// RenderHTTPWithLayout render in the best match for the request's
+// Accept-Language header, with "Vary: Accept-Language", when there is one.
 func WithI18n(defaultLang string, translations map[string]map[string]string) Option {
 	return func(c *Config) {
-		c.I18n = &I18nConfig{
+		i18n := &I18nConfig{
 			DefaultLang:  defaultLang,
 			Translations: translations,
 			currentLang:  defaultLang,
 		}
+		if c.I18n != nil {
+			i18n.Translator = c.I18n.Translator
+			i18n.LocaleFormats = c.I18n.LocaleFormats
+			i18n.Dir = c.I18n.Dir
+		}
+		c.I18n = i18n
+	}
+}
+
+// WithI18nDir loads translations from the JSON files in dir, one per
+// language named after its code, such as fr.json holding {"hello":
+// "Bonjour"}. They replace the WithI18n translations of the same language.
+// In development mode the files are reread along with the templates, and
+// the keys added, removed or modified in each language are logged, so
+// translators can see their edits took effect.
+func WithI18nDir(dir string) Option {
+	return func(c *Config) {
+		if c.I18n == nil {
+			c.I18n = &I18nConfig{}
+		}
+		c.I18n.Dir = dir
+	}
+}
+
+// WithLocaleFormats sets the number, currency and date formats of
+// languages, overriding the built-in ones
+func WithLocaleFormats(formats map[string]LocaleFormat) Option {
+	return func(c *Config) {
+		if c.I18n == nil {
+			c.I18n = &I18nConfig{}
+		}
+		if c.I18n.LocaleFormats == nil {
+			c.I18n.LocaleFormats = map[string]LocaleFormat{}
+		}
+		maps.Copy(c.I18n.LocaleFormats, formats)
+	}
+}
+
+// WithTranslator routes the t helper, and the translatable strings of the
+// humanizing helpers, through tr instead of the WithI18n translation maps.
+// Combine it with WithI18n to set the default language.
+func WithTranslator(tr Translator) Option {
+	return func(c *Config) {
+		if c.I18n == nil {
+			c.I18n = &I18nConfig{}
+		}
+		c.I18n.Translator = tr
+	}
+}
+
+// WithStrictFuncs makes helper errors non-fatal in development mode. Each
+// error is annotated with the helper and template name, rendering goes on,
+// and all errors are returned together once the template has executed.
+func WithStrictFuncs(strict bool) Option {
+	return func(c *Config) {
+		c.StrictFuncs = strict
+	}
+}
+
+// WithNamePrefix prefixes the name of every template parsed by the engine,
+// so "button" becomes "ui:button". References between templates of the
+// same engine are rewritten to the prefixed names.
+func WithNamePrefix(prefix string) Option {
+	return func(c *Config) {
+		c.NamePrefix = prefix
+	}
+}
+
+// WithRequestData sets a function that supplies per-request values (current
+// path, user, CSRF token, ...) to the HTTP render helpers. Page data passed
+// by the handler takes precedence over request data on key conflicts; maps
+// and structs are merged by key or exported field, other data is kept under
+// .Data.
+func WithRequestData(fn func(*http.Request) map[string]any) Option {
+	return func(c *Config) {
+		c.RequestData = fn
+	}
+}
+
+// WithContentType sets the Content-Type the HTTP render helpers send for the
+// named template, overriding detection by the template's extension
+func WithContentType(name, contentType string) Option {
+	return func(c *Config) {
+		if c.ContentTypes == nil {
+			c.ContentTypes = map[string]string{}
+		}
+		c.ContentTypes[name] = contentType
+	}
+}
+
+// WithMaxRenderDepth limits how deeply partial and embed calls may nest
+// within one render. Exceeding it aborts the render with an error instead
+// of overflowing the stack on recursive templates.
+func WithMaxRenderDepth(n int) Option {
+	return func(c *Config) {
+		c.MaxDepth = n
+	}
+}
+
+// WithNotFoundTemplate sets a template Render falls back to when asked for
+// an unknown template. Render then returns ErrTemplateNotFound, which
+// callers can map to a 404 response.
+func WithNotFoundTemplate(name string) Option {
+	return func(c *Config) {
+		c.NotFound = name
+	}
+}
+
+// WithTimezone sets the timezone date helpers render times in. It defaults
+// to the server's local timezone.
+func WithTimezone(loc *time.Location) Option {
+	return func(c *Config) {
+		c.Location = loc
+	}
+}
+
+// WithDataLoaders registers named data loaders. Before a template executes,
+// the loaders it asks for with {{load "key"}} are run concurrently under
+// one deadline, and load returns their results.
+func WithDataLoaders(loaders map[string]func(context.Context) (any, error)) Option {
+	return func(c *Config) {
+		if c.Loaders == nil {
+			c.Loaders = map[string]func(context.Context) (any, error){}
+		}
+		maps.Copy(c.Loaders, loaders)
+	}
+}
+
+// WithLoaderTimeout sets the deadline shared by the data loaders of one
+// render
+func WithLoaderTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.LoaderTimeout = d
+	}
+}
+
+// WithFragmentDetector sets how the HTTP render helpers tell fragment
+// requests, which are rendered without a layout, from full page requests.
+// The default is IsFragmentRequest. vary names the request headers fn
+// reads, which responses list in Vary; without any, HX-Request is assumed.
+func WithFragmentDetector(fn func(*http.Request) bool, vary ...string) Option {
+	return func(c *Config) {
+		c.FragmentDetector = fn
+		c.FragmentVary = vary
+	}
+}
+
+// WithAutoAssetVersion derives each asset's version from a hash of its
+// content instead of AssetVersion or, in development, the current time.
+// Identical files always produce identical URLs, which keeps builds
+// reproducible and lets CDNs cache by content.
+func WithAutoAssetVersion(enable bool) Option {
+	return func(c *Config) {
+		c.AutoAssetVersion = enable
+	}
+}
+
+// WithGlobals sets values merged into the data of every render. Page data
+// wins on key conflicts. Maps and structs are merged by key or exported
+// field, other data is kept under .Data; see Render for nil data.
+func WithGlobals(globals map[string]any) Option {
+	return func(c *Config) {
+		if c.Globals == nil {
+			c.Globals = map[string]any{}
+		}
+		maps.Copy(c.Globals, globals)
+	}
+}
+
+// WithCSP sets the Content-Security-Policy sent by SecurityHeaders and
+// makes the HTTP render helpers send security headers with a fresh nonce
+// on every response. "{nonce}" in the policy is replaced by the nonce,
+// which templates read as .CSPNonce beside the fields of the page data;
+// pass "" to use DefaultCSP.
+func WithCSP(policy string) Option {
+	return func(c *Config) {
+		if policy == "" {
+			policy = DefaultCSP
+		}
+		c.CSP = policy
+	}
+}
+
+// WithProfile groups options under a named profile, such as "dev" or
+// "prod", that only apply when selected with WithActiveProfile. Profile
+// options are applied after all other options, so they override the base
+// configuration; within a profile, and across repeated WithProfile calls
+// for the same name, later options win.
+func WithProfile(name string, opts ...Option) Option {
+	return func(c *Config) {
+		if c.profiles == nil {
+			c.profiles = map[string][]Option{}
+		}
+		c.profiles[name] = append(c.profiles[name], opts...)
+	}
+}
+
+// WithActiveProfile selects the profile applied when the engine is
+// configured. An empty name applies no profile.
+func WithActiveProfile(name string) Option {
+	return func(c *Config) {
+		c.Profile = name
+	}
+}
+
+// WithDataTransform sets a function applied to the data of the named
+// template just before it executes, whether it is rendered directly, as the
+// view or layout of RenderWithLayout, or through partial or embed. It sees
+// the data as passed, before globals are merged. An error aborts the
+// render.
+func WithDataTransform(name string, fn func(any) (any, error)) Option {
+	return func(c *Config) {
+		if c.DataTransforms == nil {
+			c.DataTransforms = map[string]func(any) (any, error){}
+		}
+		c.DataTransforms[name] = fn
+	}
+}
+
+// WithWriteTimeout aborts renders whose output is not written within d,
+// with an error matching os.ErrDeadlineExceeded. Output written before the
+// deadline has gone out, so the client sees a truncated page.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.WriteTimeout = d
+	}
+}
+
+// WithAssetURLRewriter rewrites every versioned asset URL, e.g. to point
+// assets at a CDN
+func WithAssetURLRewriter(fn func(url string) string) Option {
+	return func(c *Config) {
+		c.AssetURLRewriter = fn
+	}
+}
+
+// WithRenderHeaders makes the HTTP render helpers report the render time,
+// output size and output mode in X-Render-Time, X-Render-Size,
+// X-Render-Mode and Server-Timing headers, for browser dev tools. It only
+// takes effect in development mode, so internals never leak in
+// production; there the output is buffered until the render completes.
+func WithRenderHeaders(enable bool) Option {
+	return func(c *Config) {
+		c.RenderHeaders = enable
+	}
+}
+
+// WithLaxSections makes RenderComposite isolate failing sections: the
+// page is rendered with them left empty and their errors are returned
+// afterwards, instead of the first failure aborting the page.
+func WithLaxSections(lax bool) Option {
+	return func(c *Config) {
+		c.LaxSections = lax
+	}
+}
+
+// WithUnescapedTemplates renders the named templates without contextual
+// escaping, for JSON, XML or text output. Never serve them as HTML.
+func WithUnescapedTemplates(names []string) Option {
+	return func(c *Config) {
+		c.Unescaped = append(c.Unescaped, names...)
+	}
+}
+
+// WithPanicTemplate sets a template rendered in place of a page whose
+// render panics, such as a branded 500 page. Renders are buffered so the
+// panic page never follows partial output. The panic template gets the
+// error as .Error and the failed template's name as .Template, and Render
+// returns an error wrapping ErrRenderPanic. Other render errors are
+// returned as usual.
+func WithPanicTemplate(name string) Option {
+	return func(c *Config) {
+		c.PanicTemplate = name
+	}
+}
+
+// WithAutoTrim trims the whitespace around every action at parse time, as
+// if each were written with {{- and -}}, for tighter output without trim
+// markers everywhere. Whitespace inside <pre> and <textarea> is kept, as is
+// text produced by actions themselves, such as {{" "}}.
+func WithAutoTrim(enable bool) Option {
+	return func(c *Config) {
+		c.AutoTrim = enable
+	}
+}
+
+// WithActiveTags sets the active build tags. Templates declaring tags with
+// {{/* tags: [admin] */}} at the top are only parsed when one is active.
+func WithActiveTags(tags []string) Option {
+	return func(c *Config) {
+		c.ActiveTags = append(c.ActiveTags, tags...)
+	}
+}
+
+// WithOutputBOM prepends a UTF-8 byte order mark to the output of Render,
+// RenderContext, RenderWithLayout and the HTTP helpers, for consumers such
+// as Excel that need one to detect UTF-8. Output that already starts with
+// a BOM doesn't get a second one.
+func WithOutputBOM(enable bool) Option {
+	return func(c *Config) {
+		c.OutputBOM = enable
+	}
+}
+
+// WithCharset sets the charset the HTTP helpers declare in Content-Type,
+// instead of utf-8. Content types set with WithContentType are sent as
+// given. Only the header changes: output is not transcoded, so the charset
+// must be one the rendered UTF-8 bytes are valid in.
+func WithCharset(charset string) Option {
+	return func(c *Config) {
+		c.Charset = charset
+	}
+}
+
+// WithAlias makes oldName render newName, so callers keep working while a
+// template is renamed. Aliases resolve in Render and the other render
+// methods, partial, and HasTemplate, and may point at other aliases. Each
+// use logs a deprecation warning in development mode. An alias sharing a
+// name with a real template, or a cycle of aliases, fails engine creation.
+func WithAlias(oldName, newName string) Option {
+	return func(c *Config) {
+		if c.Aliases == nil {
+			c.Aliases = map[string]string{}
+		}
+		c.Aliases[oldName] = newName
+	}
+}
+
+// WithNoMemo lists partials that render on every call instead of reusing
+// output for repeated data. Partials with known side effects or data
+// holding pointers, maps or slices are never reused.
+func WithNoMemo(names ...string) Option {
+	return func(c *Config) {
+		c.NoMemo = append(c.NoMemo, names...)
+	}
+}
+
+// WithParseTimeFuncCheck sets whether every function the templates call
+// must exist when the engine is created, turning a missing function into a
+// startup error rather than a failed render. It is on by default. When
+// off, templates calling unknown functions still parse, and a call fails
+// the render only if execution reaches it.
+func WithParseTimeFuncCheck(enable bool) Option {
+	return func(c *Config) {
+		c.FuncCheck = enable
+	}
+}
+
+// WithBufferedOutput renders each page to memory before writing it, so a
+// render that fails writes nothing and the caller can still send an error
+// page. It costs a copy of every page; without it, and without a panic
+// template, Render executes straight into the writer. See OutputMode.
+func WithBufferedOutput(enable bool) Option {
+	return func(c *Config) {
+		c.Buffered = enable
+	}
+}
+
+// WithErrorPlaceholder sets the template RenderIsolated renders in place of
+// a partial or embed that fails, with the error as .Error and the failed
+// template as .Template. Without one, failed calls render nothing.
+func WithErrorPlaceholder(name string) Option {
+	return func(c *Config) {
+		c.ErrorPlaceholder = name
+	}
+}
+
+// WithOutputCheck makes Render and RenderWithLayout log markup problems in
+// their output, such as a <div> left open by a bad conditional or an end
+// tag that closes the wrong element. It only takes effect in development
+// mode, where each page is kept in memory to be checked.
+func WithOutputCheck(enable bool) Option {
+	return func(c *Config) {
+		c.CheckOutput = enable
+	}
+}
+
+// WithAMPCheck makes Render and RenderWithLayout log output that breaks
+// common AMP rules, such as inline styles, <img> instead of <amp-img> or a
+// missing canonical link, for pages whose <html> tag is marked "amp" or
+// "⚡". Like WithOutputCheck it only takes effect in development mode. It
+// is not a substitute for the AMP validator.
+func WithAMPCheck(enable bool) Option {
+	return func(c *Config) {
+		c.CheckAMP = enable
+	}
+}
+
+// WithBeforeRender sets a hook that can replace the data of every page
+// render, after its transform and globals; partials don't trigger it
+func WithBeforeRender(fn func(name string, data any) (any, error)) Option {
+	return func(c *Config) {
+		c.BeforeRender = fn
+	}
+}
+
+// WithStrictConfig makes CreateEngine fail on options that have no effect
+// or contradict each other, such as WithRenderHeaders outside development
+// mode or a panic template that doesn't exist. Without it those problems
+// are logged as warnings.
+func WithStrictConfig(strict bool) Option {
+	return func(c *Config) {
+		c.StrictConfig = strict
+	}
+}
+
+// WithTransformFor registers a transform of the rendered output of the
+// templates whose name or file below the template directory matches
+// pattern, a path.Match glob, such as a minifier for "pages/*" that leaves
+// "emails/*" alone. For layout renders
+// the view's name is matched. Transforms run in registration order, each
+// on the output of the last; the output of matching templates is buffered
+// so they see it whole.
+func WithTransformFor(pattern string, fn func([]byte) ([]byte, error)) Option {
+	return func(c *Config) {
+		c.OutputTransforms = append(c.OutputTransforms, OutputTransform{Pattern: pattern, Fn: fn})
+	}
+}
+
+// WithBuildInfo sets the build details reported by WithBuildComment
+func WithBuildInfo(info BuildInfo) Option {
+	return func(c *Config) {
+		c.Build = info
+	}
+}
+
+// WithBuildComment appends an HTML comment with the asset version, build
+// time and commit to every rendered page
+func WithBuildComment(enable bool) Option {
+	return func(c *Config) {
+		c.BuildComment = enable
+	}
+}
+
+// WithMaxIncludeDepth fails engine creation, and development reloads, when
+// a template reaches another through more than n nested {{template}} or
+// include helper calls, catching accidentally deep template graphs before
+// they render. Only includes with a literal name are followed. Recursive
+// templates are allowed: a cycle ends the chain it is on and is logged in
+// development mode. WithMaxRenderDepth limits nesting at render time.
+func WithMaxIncludeDepth(n int) Option {
+	return func(c *Config) {
+		c.MaxIncludeDepth = n
+	}
+}
+
+// WithSafeAttrs lets the safeAttr helper produce attributes beyond its
+// built-in allow-list, such as "href" or "style". Values of URL attributes
+// are still checked for a safe scheme, but other values are only escaped:
+// allowing "style" lets data set arbitrary CSS on the element.
+func WithSafeAttrs(names ...string) Option {
+	return func(c *Config) {
+		for _, name := range names {
+			c.SafeAttrs = append(c.SafeAttrs, strings.ToLower(name))
+		}
+	}
+}
+
+// WithSlowRenderThreshold logs a warning naming the template and the time
+// taken whenever a render exceeds d, in production as well as development.
+// The render itself is not interrupted.
+func WithSlowRenderThreshold(d time.Duration) Option {
+	return func(c *Config) {
+		c.SlowRender = d
+	}
+}
+
+// WithFuncRegistry adds the functions of r, and those of the registries it
+// includes, like WithFuncs. Later options override earlier ones.
+func WithFuncRegistry(r *FuncRegistry) Option {
+	return WithFuncs(r.FuncMap())
+}
+
+// WithDeprecatedFuncs marks functions as deprecated, mapping each name to
+// advice such as "use formatDate instead". In development every call to
+// one is logged with its template and location whenever the templates are
+// parsed.
+func WithDeprecatedFuncs(funcs map[string]string) Option {
+	return func(c *Config) {
+		if c.DeprecatedFuncs == nil {
+			c.DeprecatedFuncs = map[string]string{}
+		}
+		maps.Copy(c.DeprecatedFuncs, funcs)
+	}
+}
+
+// WithWatchDebounce makes development mode reparse the templates at most
+// once per d instead of on every render: changes saved within the window,
+// such as a burst of writes from an editor or build tool, are picked up
+// together by the first render after it.
+func WithWatchDebounce(d time.Duration) Option {
+	return func(c *Config) {
+		c.WatchDebounce = d
+	}
+}
+
+// WithWatchIgnore makes development mode reparse the templates only when a
+// template or layout file not matching one of patterns has changed since
+// the last load. Patterns are path.Match globs tried against the path
+// below the template directory and against the file name, such as "*.swp"
+// or "drafts/*".
+func WithWatchIgnore(patterns ...string) Option {
+	return func(c *Config) {
+		c.WatchIgnore = append(c.WatchIgnore, patterns...)
+	}
+}
+
+// WithRelativeIncludes resolves "./" and "../" names given to partial and
+// embed against the calling template's directory
+func WithRelativeIncludes() Option {
+	return func(c *Config) {
+		c.RelativeIncludes = true
+	}
+}
+
+// WithLangCookie makes RenderHTTP and RenderHTTPWithLayout render each
+// request in the language named by the cookie called name, when it is one
+// the translations cover, else in the best match for the request's
+// Accept-Language header, else in DefaultLang. The language applies to
+// that render only; the engine's current language is not changed. Those
+// responses get "Vary: Cookie" on top of "Vary: Accept-Language".
+func WithLangCookie(name string) Option {
+	return func(c *Config) {
+		c.LangCookie = name
+	}
+}
+
+// WithSizeStats records the output size of every successful render, for
+// HTMLTemplate.SizeStats to report the smallest, largest and average size
+// of each template. A template whose output suddenly grows, say from a
+// pagination bug that dumps every row, shows up as a jump in its maximum.
+func WithSizeStats(enable bool) Option {
+	return func(c *Config) {
+		c.SizeStats = enable
+	}
+}
+
+// WithPathResolution sets the order in which ResolvePath tries names for a
+// path, each candidate a name with {name} standing for the path, such as
+// "{name}/index.html" or "pages/{name}.tmpl". The default is "{name}",
+// "{name}.html", "{name}/index", "{name}/index.html".
+func WithPathResolution(candidates ...string) Option {
+	return func(c *Config) {
+		c.PathCandidates = candidates
+	}
+}
+
+// WithTemplateOverwrite lets AddTemplate and RegisterStructTemplates
+// replace templates of the same name, including ones parsed from files,
+// instead of failing
+func WithTemplateOverwrite(enable bool) Option {
+	return func(c *Config) {
+		c.Overwrite = enable
 	}
 }
@@ -0,0 +1,30 @@
+package html
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	opts := []Option{
+		WithProfile("dev", WithDevelopment(true), WithMaxRenderDepth(4)),
+		WithActiveProfile("dev"),
+		WithMaxRenderDepth(8),
+		WithProfile("dev", WithMaxRenderDepth(2)),
+		WithProfile("prod", WithNotFoundTemplate("404.html")),
+	}
+	c := Sparkle("*.html", opts...).(*html).config
+	if !c.Development || c.MaxDepth != 2 || c.NotFound != "" {
+		t.Fatalf("dev profile: Development %v, MaxDepth %d, NotFound %q", c.Development, c.MaxDepth, c.NotFound)
+	}
+
+	c = Sparkle("*.html", append(opts, WithActiveProfile(""))...).(*html).config
+	if c.Development || c.MaxDepth != 8 {
+		t.Fatalf("no profile: Development %v, MaxDepth %d", c.Development, c.MaxDepth)
+	}
+
+	_, err := Sparkle("*.html", WithActiveProfile("staging")).CreateEngine()
+	if err == nil || !strings.Contains(err.Error(), "profile staging not defined") {
+		t.Fatalf("got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package html
+
+import (
+	"fmt"
+)
+
+// Pagination describes a page of a paginated list, as built by paginate
+type Pagination struct {
+	Total      int // items in the whole list
+	PerPage    int
+	Current    int // current page, from 1
+	TotalPages int
+	Offset     int // index of the current page's first item
+	HasPrev    bool
+	HasNext    bool
+	PrevPage   int // 0 when there is none
+	NextPage   int // 0 when there is none
+	Pages      []int
+}
+
+// maxPagesWindow caps the pages listed on each side of the current one, so
+// a huge page count never builds a huge Pages slice
+const maxPagesWindow = 50
+
+// paginate builds the pagination of total items, perPage to a page, with
+// current clamped to the existing pages. An optional window limits Pages
+// to that many pages on each side of the current one. Without one, Pages
+// lists every page, up to maxPagesWindow on each side.
+func paginate(total, perPage, current any, window ...int) (*Pagination, error) {
+	var n [3]int
+	for i, v := range []any{total, perPage, current} {
+		if num, isInt := toInt64(v); isInt {
+			n[i] = int(num)
+			continue
+		}
+		f, err := toFloat64(v)
+		if err != nil {
+			return nil, fmt.Errorf("paginate: %w", err)
+		}
+		n[i] = int(f)
+	}
+
+	p := &Pagination{Total: max(n[0], 0), PerPage: n[1]}
+	if p.PerPage < 1 {
+		p.PerPage = max(p.Total, 1)
+	}
+	if p.Total > 0 {
+		p.TotalPages = (p.Total-1)/p.PerPage + 1
+	}
+	p.Current = min(max(n[2], 1), max(p.TotalPages, 1))
+	p.Offset = (p.Current - 1) * p.PerPage
+
+	p.HasPrev = p.Current > 1
+	p.HasNext = p.Current < p.TotalPages
+	if p.HasPrev {
+		p.PrevPage = p.Current - 1
+	}
+	if p.HasNext {
+		p.NextPage = p.Current + 1
+	}
+
+	w := maxPagesWindow
+	if len(window) > 0 && window[0] >= 0 {
+		w = min(window[0], maxPagesWindow)
+	}
+	first := max(p.Current-w, 1)
+	last := min(p.Current+w, p.TotalPages)
+	for page := first; page <= last; page++ {
+		p.Pages = append(p.Pages, page)
+	}
+	return p, nil
+}
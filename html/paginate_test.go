@@ -0,0 +1,57 @@
+package html
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name                 string
+		total, perPage, page any
+		window               []int
+		want                 Pagination
+	}{
+		{"first", 45, 10, 1, nil, Pagination{Total: 45, PerPage: 10, Current: 1, TotalPages: 5, HasNext: true, NextPage: 2, Pages: []int{1, 2, 3, 4, 5}}},
+		{"last", 45, 10, 5, nil, Pagination{Total: 45, PerPage: 10, Current: 5, TotalPages: 5, Offset: 40, HasPrev: true, PrevPage: 4, Pages: []int{1, 2, 3, 4, 5}}},
+		{"middle windowed", 100, 10, 5, []int{1}, Pagination{Total: 100, PerPage: 10, Current: 5, TotalPages: 10, Offset: 40, HasPrev: true, HasNext: true, PrevPage: 4, NextPage: 6, Pages: []int{4, 5, 6}}},
+		{"empty", 0, 10, 1, nil, Pagination{PerPage: 10, Current: 1}},
+		{"past the end", 45, 10, 9, nil, Pagination{Total: 45, PerPage: 10, Current: 5, TotalPages: 5, Offset: 40, HasPrev: true, PrevPage: 4, Pages: []int{1, 2, 3, 4, 5}}},
+		{"zero per page", 45, 0, 3, nil, Pagination{Total: 45, PerPage: 45, Current: 1, TotalPages: 1, Pages: []int{1}}},
+		{"mixed types", 45.0, uint8(10), int64(2), nil, Pagination{Total: 45, PerPage: 10, Current: 2, TotalPages: 5, Offset: 10, HasPrev: true, HasNext: true, PrevPage: 1, NextPage: 3, Pages: []int{1, 2, 3, 4, 5}}},
+	}
+	for _, tt := range tests {
+		got, err := paginate(tt.total, tt.perPage, tt.page, tt.window...)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+
+	huge, err := paginate(int64(math.MaxInt64), 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if huge.TotalPages != math.MaxInt64 || len(huge.Pages) != maxPagesWindow+1 {
+		t.Fatalf("huge list: %d pages, %d listed", huge.TotalPages, len(huge.Pages))
+	}
+	if wide, _ := paginate(1e18, 1, 500, 1<<62); len(wide.Pages) != 2*maxPagesWindow+1 || wide.Pages[0] != 450 {
+		t.Fatalf("wide window listed %d pages from %d", len(wide.Pages), wide.Pages[0])
+	}
+
+	if _, err := paginate("many", 10, 1); err == nil {
+		t.Fatal("non-numeric total: want an error")
+	}
+}
+
+func TestPaginateTemplate(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"list.html": `{{with paginate .Total 10 .Page 1}}{{if .HasPrev}}<a href="?p={{.PrevPage}}">prev</a>{{end}}{{range .Pages}}[{{.}}]{{end}}{{end}}`,
+	})
+	if got := renderString(t, h, "list.html", map[string]int{"Total": 95, "Page": 4}); got != `<a href="?p=3">prev</a>[3][4][5]` {
+		t.Fatalf("got %q", got)
+	}
+}
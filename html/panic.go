@@ -0,0 +1,104 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"reflect"
+	"runtime"
+)
+
+// ErrRenderPanic is returned, wrapped, when a render panics and the panic
+// template is rendered in its place
+var ErrRenderPanic = errors.New("render panicked")
+
+// panicError carries the value a helper panicked with
+type panicError struct {
+	value any
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+func (e *panicError) Unwrap() error {
+	return ErrRenderPanic
+}
+
+// isPanic reports whether a render error comes from a panic. text/template
+// turns panics in helpers and methods into execution errors; helpers are
+// marked by recoverFuncs, and nil dereferences and the like surface as
+// runtime errors.
+func isPanic(err error) bool {
+	var rt runtime.Error
+	return errors.Is(err, ErrRenderPanic) || errors.As(err, &rt)
+}
+
+// recoverFuncs wraps every function so that a panic is re-raised as a
+// panicError, which text/template hands back as the call's error
+func recoverFuncs(funcs template.FuncMap) {
+	for name, fn := range funcs {
+		v := reflect.ValueOf(fn)
+		if v.Kind() != reflect.Func {
+			continue
+		}
+		t := v.Type()
+		funcs[name] = reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+			defer func() {
+				if r := recover(); r != nil {
+					panic(&panicError{value: r})
+				}
+			}()
+			if t.IsVariadic() {
+				return v.CallSlice(args)
+			}
+			return v.Call(args)
+		}).Interface()
+	}
+}
+
+// renderRecovering runs exec, which renders name, to a buffer and writes
+// it to w. When the render panics, the panic template is rendered in its
+// place, with the panic as .Error and the failed template as .Template, and
+// the result is an error wrapping ErrRenderPanic. A panic in the panic
+// template itself is returned as is rather than handled again.
+func (h *HTMLTemplate) renderRecovering(ctx context.Context, w io.Writer, name string, exec func(io.Writer) error) error {
+	var buf bytes.Buffer
+	err := recovering(name, func() error { return exec(&buf) })
+	if err == nil || !isPanic(err) {
+		if _, werr := buf.WriteTo(w); err == nil {
+			err = werr
+		}
+		return err
+	}
+
+	var page bytes.Buffer
+	pageData := map[string]any{"Error": err, "Template": name}
+	perr := recovering(h.config.PanicTemplate, func() error {
+		return h.execute(ctx, &page, h.config.PanicTemplate, pageData)
+	})
+	if perr != nil {
+		return errors.Join(err, fmt.Errorf("panic template %s: %w", h.config.PanicTemplate, perr))
+	}
+	if _, werr := page.WriteTo(w); werr != nil {
+		return errors.Join(err, werr)
+	}
+	if !errors.Is(err, ErrRenderPanic) {
+		err = fmt.Errorf("%w: %w", ErrRenderPanic, err)
+	}
+	return err
+}
+
+// recovering runs fn, which renders name, with panics that escape
+// text/template turned into errors
+func recovering(name string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("template %s: %w", name, &panicError{value: r})
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,73 @@
+package html
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"testing"
+)
+
+func TestPanicTemplate(t *testing.T) {
+	funcs := template.FuncMap{"boom": func() (string, error) { panic("kaboom") }}
+	h := newTestEngine(t, map[string]string{
+		"page.html":  `<p>before</p>{{boom}}`,
+		"fail.html":  `<p>before</p>{{template "nope"}}`,
+		"panic.html": `<h1>oops in {{.Template}}</h1>`,
+	}, WithFuncs(funcs), WithPanicTemplate("panic.html"))
+
+	if h.OutputMode() != OutputBuffered {
+		t.Fatal("a panic template must buffer output")
+	}
+
+	var buf bytes.Buffer
+	err := h.Render(&buf, "page.html", nil)
+	if !errors.Is(err, ErrRenderPanic) {
+		t.Fatalf("got error %v, want ErrRenderPanic", err)
+	}
+	if got := buf.String(); got != "<h1>oops in page.html</h1>" {
+		t.Fatalf("got %q", got)
+	}
+
+	buf.Reset()
+	err = h.Render(&buf, "fail.html", nil)
+	if err == nil || errors.Is(err, ErrRenderPanic) {
+		t.Fatalf("plain error got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("failed render wrote %q", buf.String())
+	}
+}
+
+func TestPanicTemplatePanics(t *testing.T) {
+	funcs := template.FuncMap{"boom": func() (string, error) { panic("kaboom") }}
+	h := newTestEngine(t, map[string]string{
+		"page.html":  `{{boom}}`,
+		"panic.html": `{{boom}}`,
+	}, WithFuncs(funcs), WithPanicTemplate("panic.html"))
+
+	var buf bytes.Buffer
+	err := h.Render(&buf, "page.html", nil)
+	if !errors.Is(err, ErrRenderPanic) || buf.Len() != 0 {
+		t.Fatalf("got %q, %v", buf.String(), err)
+	}
+}
+
+func TestPanicTemplateLayout(t *testing.T) {
+	funcs := template.FuncMap{"boom": func() (string, error) { panic("kaboom") }}
+	for _, cache := range []bool{false, true} {
+		h := newTestEngine(t, map[string]string{
+			"page.html":         `{{boom}}`,
+			"panic.html":        `<h1>oops in {{.Template}}</h1>`,
+			"layouts/base.html": `<main>before{{template "content" .}}</main>`,
+		}, WithFuncs(funcs), WithPanicTemplate("panic.html"), WithLayoutDir("layouts"), WithCache(cache))
+
+		var buf bytes.Buffer
+		err := h.RenderWithLayout(&buf, &RenderData{Layout: "base", View: "page.html"})
+		if !errors.Is(err, ErrRenderPanic) {
+			t.Fatalf("cache %v: got error %v, want ErrRenderPanic", cache, err)
+		}
+		if got := buf.String(); got != "<h1>oops in page.html</h1>" {
+			t.Fatalf("cache %v: got %q", cache, got)
+		}
+	}
+}
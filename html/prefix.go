@@ -0,0 +1,42 @@
+package html
+
+import (
+	"html/template"
+	"text/template/parse"
+)
+
+// prefixNames rebuilds t with NamePrefix in front of every template name,
+// rewriting {{template}} calls that refer to templates in the set
+func (c *Config) prefixNames(t *template.Template, layouts []string) (*template.Template, []string, error) {
+	names := map[string]bool{}
+	for _, tpl := range t.Templates() {
+		names[tpl.Name()] = true
+	}
+
+	nt := template.New("").Delims(c.Delimiters[0], c.Delimiters[1]).Funcs(c.mergeFuncs())
+	for _, tpl := range t.Templates() {
+		if tpl.Name() == "" || tpl.Tree == nil {
+			continue
+		}
+
+		tree := tpl.Tree.Copy()
+		tree.Name = c.NamePrefix + tree.Name
+		walkTree(tree.Root, func(node parse.Node) bool {
+			if n, ok := node.(*parse.TemplateNode); ok && names[n.Name] {
+				n.Name = c.NamePrefix + n.Name
+			}
+			return true
+		})
+
+		if _, err := nt.AddParseTree(tree.Name, tree); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	prefixed := make([]string, len(layouts))
+	for i, name := range layouts {
+		prefixed[i] = c.NamePrefix + name
+	}
+
+	return nt, prefixed, nil
+}
@@ -0,0 +1,35 @@
+package html
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestNamePrefix(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":         `{{define "btn"}}<b>{{.}}</b>{{end}}{{template "btn" .}}`,
+		"layouts/base.html": `<main>{{template "content" .}}</main>`,
+	}, WithNamePrefix("ui:"), WithLayoutDir("layouts"))
+
+	if got := renderString(t, h, "ui:page.html", "go"); got != "<b>go</b>" {
+		t.Fatalf("got %q", got)
+	}
+	if got := renderString(t, h, "ui:btn", "x"); got != "<b>x</b>" {
+		t.Fatalf("define got %q", got)
+	}
+	if h.HasTemplate("page.html") || h.HasTemplate("btn") {
+		t.Fatal("unprefixed names are still defined")
+	}
+
+	if got := h.LayoutNames(); !slices.Equal(got, []string{"ui:base"}) {
+		t.Fatalf("LayoutNames() = %q", got)
+	}
+	var buf bytes.Buffer
+	if err := h.RenderWithLayout(&buf, &RenderData{View: "ui:page.html", Layout: "ui:base", Data: "y"}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "<main><b>y</b></main>" {
+		t.Fatalf("layout got %q", buf.String())
+	}
+}
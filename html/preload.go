@@ -0,0 +1,28 @@
+package html
+
+import (
+	"fmt"
+	"html/template"
+	"slices"
+)
+
+// preloadTypes are the destinations preload accepts. "module" preloads a
+// JavaScript module with rel="modulepreload".
+var preloadTypes = []string{"audio", "document", "embed", "fetch", "font", "image", "module", "object", "script", "style", "track", "video", "worker"}
+
+// preload returns a preload <link> for the asset's versioned URL, as is
+// the destination such as "style" or "font"; "module" gives modulepreload
+func (c *Config) preload(name, as string) (template.HTML, error) {
+	if !slices.Contains(preloadTypes, as) {
+		return "", fmt.Errorf("preload %s: unknown destination %q", name, as)
+	}
+
+	href := template.HTMLEscapeString(c.assetPath(name))
+	switch as {
+	case "module":
+		return template.HTML(`<link rel="modulepreload" href="` + href + `">`), nil
+	case "font", "fetch":
+		return template.HTML(`<link rel="preload" href="` + href + `" as="` + as + `" crossorigin>`), nil
+	}
+	return template.HTML(`<link rel="preload" href="` + href + `" as="` + as + `">`), nil
+}
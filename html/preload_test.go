@@ -0,0 +1,30 @@
+package html
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreload(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"style.html":  `{{preload "app.css" "style"}}`,
+		"font.html":   `{{preload "fonts/a.woff2" "font"}}`,
+		"module.html": `{{preload "app.js" "module"}}`,
+		"bad.html":    `{{preload "app.css" "stylesheet"}}`,
+	}, WithAssetDir("static"), WithAssetVersion("3"))
+
+	tests := map[string]string{
+		"style.html":  `<link rel="preload" href="static/app.css?v=3" as="style">`,
+		"font.html":   `<link rel="preload" href="static/fonts/a.woff2?v=3" as="font" crossorigin>`,
+		"module.html": `<link rel="modulepreload" href="static/app.js?v=3">`,
+	}
+	for name, want := range tests {
+		if got := renderString(t, h, name, nil); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+
+	if err := h.Render(&strings.Builder{}, "bad.html", nil); err == nil || !strings.Contains(err.Error(), `unknown destination "stylesheet"`) {
+		t.Fatalf("got %v", err)
+	}
+}
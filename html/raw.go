@@ -0,0 +1,32 @@
+package html
+
+import (
+	htmltemplate "html/template"
+	"slices"
+	"text/template"
+)
+
+// rawSet builds a text/template set from the pristine trees of base, used
+// to render the templates listed in Unescaped. It returns nil when
+// none are listed.
+func (c *Config) rawSet(base *htmltemplate.Template) (*template.Template, error) {
+	if len(c.Unescaped) == 0 {
+		return nil, nil
+	}
+
+	raw := template.New("").Funcs(template.FuncMap(c.mergeFuncs()))
+	for _, tpl := range base.Templates() {
+		if tpl.Tree == nil || tpl.Name() == "" {
+			continue
+		}
+		if _, err := raw.AddParseTree(tpl.Name(), tpl.Tree.Copy()); err != nil {
+			return nil, err
+		}
+	}
+	return raw, nil
+}
+
+// unescaped reports whether name renders without contextual escaping
+func (c *Config) unescaped(name string) bool {
+	return slices.Contains(c.Unescaped, name)
+}
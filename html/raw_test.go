@@ -0,0 +1,18 @@
+package html
+
+import "testing"
+
+func TestUnescapedTemplates(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"feed.html": `{"title": "{{.}}", "more": {{template "part.html" .}}}`,
+		"part.html": `"<{{.}}>"`,
+		"page.html": `<p>{{.}}</p>`,
+	}, WithUnescapedTemplates([]string{"feed.html"}))
+
+	if got := renderString(t, h, "feed.html", "Tom & <Jerry>"); got != `{"title": "Tom & <Jerry>", "more": "<Tom & <Jerry>>"}` {
+		t.Fatalf("unescaped got %q", got)
+	}
+	if got := renderString(t, h, "page.html", "<b>"); got != "<p>&lt;b&gt;</p>" {
+		t.Fatalf("other templates must stay escaped, got %q", got)
+	}
+}
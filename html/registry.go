@@ -0,0 +1,68 @@
+package html
+
+import (
+	"html/template"
+	"maps"
+)
+
+// FuncRegistry is a named, versioned set of template functions that can be
+// shared between engines and built on by other registries:
+//
+//	var base = html.NewFuncRegistry("base", "v1").Add("upper", strings.ToUpper)
+//	var shop = html.NewFuncRegistry("shop", "v3").Include(base).Add("price", price)
+//
+// Functions a registry adds itself take precedence over those of the
+// registries it includes, and later includes over earlier ones.
+type FuncRegistry struct {
+	Name    string
+	Version string
+
+	funcs    template.FuncMap
+	includes []*FuncRegistry
+}
+
+// NewFuncRegistry returns an empty registry
+func NewFuncRegistry(name, version string) *FuncRegistry {
+	return &FuncRegistry{Name: name, Version: version, funcs: template.FuncMap{}}
+}
+
+// Add registers fn under name, replacing any function of that name
+func (r *FuncRegistry) Add(name string, fn any) *FuncRegistry {
+	r.funcs[name] = fn
+	return r
+}
+
+// AddMap registers every function in funcs
+func (r *FuncRegistry) AddMap(funcs template.FuncMap) *FuncRegistry {
+	maps.Copy(r.funcs, funcs)
+	return r
+}
+
+// Include makes the functions of regs part of r, below r's own
+func (r *FuncRegistry) Include(regs ...*FuncRegistry) *FuncRegistry {
+	r.includes = append(r.includes, regs...)
+	return r
+}
+
+// FuncMap returns the functions of r and the registries it includes, with
+// overrides applied
+func (r *FuncRegistry) FuncMap() template.FuncMap {
+	funcs := template.FuncMap{}
+	r.collect(funcs, map[*FuncRegistry]bool{})
+	return funcs
+}
+
+// collect copies the functions of r into funcs, skipping registries already
+// on the path so include cycles end
+func (r *FuncRegistry) collect(funcs template.FuncMap, seen map[*FuncRegistry]bool) {
+	if seen[r] {
+		return
+	}
+	seen[r] = true
+	defer delete(seen, r)
+
+	for _, inc := range r.includes {
+		inc.collect(funcs, seen)
+	}
+	maps.Copy(funcs, r.funcs)
+}
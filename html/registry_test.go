@@ -0,0 +1,38 @@
+package html
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestFuncRegistry(t *testing.T) {
+	base := NewFuncRegistry("base", "v1").
+		Add("upper", strings.ToUpper).
+		Add("greet", func(s string) string { return "hello " + s })
+	shop := NewFuncRegistry("shop", "v2").Include(base).
+		AddMap(template.FuncMap{"greet": func(s string) string { return "welcome " + s }})
+	base.Include(shop) // a cycle ends instead of recursing forever
+
+	funcs := shop.FuncMap()
+	if len(funcs) != 2 {
+		t.Fatalf("got %d functions, want 2", len(funcs))
+	}
+
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{greet (upper .)}}`,
+	}, WithFuncRegistry(shop))
+	if got := renderString(t, h, "page.html", "ann"); got != "welcome ANN" {
+		t.Fatalf("got %q", got)
+	}
+
+	// Later includes override earlier ones
+	other := NewFuncRegistry("other", "v1").Add("upper", strings.ToLower)
+	site := NewFuncRegistry("site", "v1").Include(base, other)
+	h = newTestEngine(t, map[string]string{
+		"page.html": `{{upper .}}`,
+	}, WithFuncRegistry(site))
+	if got := renderString(t, h, "page.html", "Ann"); got != "ann" {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,125 @@
+package html
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// templatePaths maps the name of each template file matching pattern, and
+// of each layout, to its path below TemplateDir
+func (c *Config) templatePaths(pattern string) map[string]string {
+	paths := map[string]string{}
+	add := func(files []string, name func(base string) string) {
+		for _, file := range files {
+			rel, err := filepath.Rel(c.TemplateDir, file)
+			if err != nil {
+				continue
+			}
+			paths[c.NamePrefix+name(filepath.Base(file))] = filepath.ToSlash(rel)
+		}
+	}
+
+	files, _ := c.glob(filepath.Join(c.TemplateDir, pattern))
+	add(files, func(base string) string { return base })
+	if c.LayoutDir != "" {
+		layouts, _ := c.glob(filepath.Join(c.TemplateDir, c.LayoutDir, "*"))
+		add(layouts, func(base string) string { return strings.TrimSuffix(base, filepath.Ext(base)) })
+	}
+	return paths
+}
+
+// templateFile returns the path below TemplateDir of the file the named
+// template was parsed from, or "" for templates defined otherwise
+func (h *HTMLTemplate) templateFile(name string) string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.paths[name]
+}
+
+// matchTemplate reports whether the path.Match pattern matches the name of
+// a template or, when it has one, its file below TemplateDir
+func matchTemplate(pattern, name, file string) bool {
+	if ok, _ := path.Match(pattern, name); ok {
+		return true
+	}
+	ok, _ := path.Match(pattern, file)
+	return ok && file != ""
+}
+
+// relative resolves a name starting with "./" or "../" against the
+// directory of the template calling the helper, the innermost partial or
+// embed or else the template being rendered, when WithRelativeIncludes is
+// set. The extension may be left out. Other names, and relative names
+// matching no template file, are returned as they are for global lookup.
+func (rs *renderState) relative(name string) string {
+	if !rs.h.config.RelativeIncludes || !strings.HasPrefix(name, "./") && !strings.HasPrefix(name, "../") {
+		return name
+	}
+
+	caller := rs.name
+	if len(rs.callers) > 0 {
+		caller = rs.callers[len(rs.callers)-1]
+	}
+
+	rs.h.mu.RLock()
+	defer rs.h.mu.RUnlock()
+
+	dir, ok := rs.h.paths[caller]
+	if !ok {
+		return name
+	}
+	target := path.Join(path.Dir(dir), name)
+
+	found := ""
+	for tpl, file := range rs.h.paths {
+		if file == target {
+			return tpl
+		}
+		if strings.TrimSuffix(file, path.Ext(file)) == target && (found == "" || tpl < found) {
+			found = tpl
+		}
+	}
+	if found != "" {
+		return found
+	}
+	return name
+}
+
+// defaultPathCandidates is the resolution order of ResolvePath without
+// WithPathResolution
+var defaultPathCandidates = []string{"{name}", "{name}.html", "{name}/index", "{name}/index.html"}
+
+// ResolvePath returns the template serving a URL-style path, trying each
+// pattern of the resolution order, so "/about/" finds about/index.html.
+// The error wraps ErrTemplateNotFound when nothing matches.
+func (h *HTMLTemplate) ResolvePath(p string) (string, error) {
+	name := strings.Trim(p, "/")
+	candidates := h.config.PathCandidates
+	if len(candidates) == 0 {
+		candidates = defaultPathCandidates
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, candidate := range candidates {
+		want := strings.Trim(strings.ReplaceAll(candidate, "{name}", name), "/")
+		if want == "" {
+			continue
+		}
+		for tpl, file := range h.paths {
+			if file == want {
+				return tpl, nil
+			}
+		}
+		// Templates from files go by their base name, so only those defined
+		// otherwise are matched by name
+		target := h.config.aliasTarget(want)
+		if _, fromFile := h.paths[target]; !fromFile && h.t.Lookup(target) != nil {
+			return target, nil
+		}
+	}
+	return "", fmt.Errorf("path %s: %w", p, ErrTemplateNotFound)
+}
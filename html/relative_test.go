@@ -0,0 +1,79 @@
+package html
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRelativeIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"pages/settings.html": `<main>{{partial "./_form" .}}{{partial "../shared/_footer.html"}}{{partial "_note.html"}}</main>`,
+		"pages/_form.html":    `<form>{{partial "./_field"}}</form>`,
+		"pages/_field.html":   `<input>`,
+		"shared/_footer.html": `<footer></footer>`,
+		"shared/_note.html":   `global`,
+	})
+	engine, err := Sparkle("*/*", WithTemplateDir(dir), WithRelativeIncludes()).CreateEngine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := engine.(*HTMLTemplate)
+
+	want := `<main><form><input></form><footer></footer>global</main>`
+	if got := renderString(t, h, "settings.html", nil); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	engine, err = Sparkle("*/*", WithTemplateDir(dir)).CreateEngine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.(*HTMLTemplate).Render(&strings.Builder{}, "settings.html", nil); err == nil {
+		t.Fatal("relative name resolved without WithRelativeIncludes")
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"about/index.html":   `about`,
+		"pages/contact.html": `contact`,
+	})
+	engine, err := Sparkle("*/*.html", WithTemplateDir(dir)).CreateEngine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := engine.(*HTMLTemplate)
+
+	tests := map[string]string{
+		"/about/":        "index.html",
+		"about":          "index.html",
+		"/pages/contact": "contact.html",
+	}
+	for p, want := range tests {
+		got, err := h.ResolvePath(p)
+		if err != nil {
+			t.Fatalf("%s: %v", p, err)
+		}
+		if got != want {
+			t.Errorf("%s: got %q, want %q", p, got, want)
+		}
+	}
+	if _, err := h.ResolvePath("/missing/"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Fatalf("missing: got %v", err)
+	}
+
+	engine, err = Sparkle("*/*.html", WithTemplateDir(dir), WithPathResolution("pages/{name}.html")).CreateEngine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = engine.(*HTMLTemplate)
+	if got, err := h.ResolvePath("/contact"); err != nil || got != "contact.html" {
+		t.Fatalf("custom order: got %q, %v", got, err)
+	}
+	if _, err := h.ResolvePath("/about/"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Fatalf("custom order: about resolved, got %v", err)
+	}
+}
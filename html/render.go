@@ -0,0 +1,588 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+	"text/template/parse"
+	"time"
+)
+
+// renderState carries values scoped to a single render call. Helpers that
+// need to know about the render in progress are bound to it on a private
+// clone of the template set, so concurrent renders never share state.
+type renderState struct {
+	h       *HTMLTemplate
+	t       *template.Template // set being executed
+	w       io.Writer
+	name    string
+	stream  bool
+	errs    []error
+	depth   int
+	embeds  []string       // embeds in progress, outermost first
+	callers []string       // partials and embeds in progress, outermost first
+	loc     *time.Location // overrides the configured timezone
+	lang    string         // overrides the current language
+	ctx     context.Context
+	loaded  map[string]any
+	trace   *Trace // innermost traced call, nil when not tracing
+	strict  bool   // collect helper errors whatever the config says
+	lazies  []lazyPartial
+	lazyN   int                    // lazy partials deferred so far
+	memo    map[string][]memoEntry // partial outputs by template name
+	onces   map[string]any         // values cached by once
+
+	captures map[string]template.HTML // outputs kept by capture
+
+	isolate  bool    // replace failed partials, see RenderIsolated
+	isolated []error // failures replaced so far
+}
+
+// statefulFuncs are the helpers that only work when bound to a renderState.
+// Templates calling any of them are always rendered on a render set.
+var statefulFuncs = []string{"partial", "embed", "load", "joinTemplates", "includeIfExists", "lazy", "once", "capture", "captured", "setLang"}
+
+// reset prepares the state for the next render
+func (rs *renderState) reset(w io.Writer, name string) {
+	*rs = renderState{h: rs.h, t: rs.t, w: w, name: name}
+}
+
+// enter records a nested render of name, failing once MaxDepth is reached.
+// Every successful enter must be paired with leave.
+func (rs *renderState) enter(kind, name string) error {
+	if rs.depth >= rs.h.config.MaxDepth {
+		return fmt.Errorf("%s %s: max render depth %d exceeded", kind, name, rs.h.config.MaxDepth)
+	}
+	rs.depth++
+	rs.callers = append(rs.callers, name)
+
+	if rs.trace != nil {
+		node := &Trace{Name: name, Kind: kind, Start: time.Now(), parent: rs.trace}
+		rs.trace.Children = append(rs.trace.Children, node)
+		rs.trace = node
+	}
+	return nil
+}
+
+// leave ends a nested render started by enter
+func (rs *renderState) leave() {
+	rs.depth--
+	rs.callers = rs.callers[:len(rs.callers)-1]
+
+	if rs.trace != nil {
+		rs.trace.Duration = time.Since(rs.trace.Start)
+		rs.trace = rs.trace.parent
+	}
+}
+
+// result combines the execution error with the errors collected by helpers
+func (rs *renderState) result(err error) error {
+	return errors.Join(append(rs.errs, err)...)
+}
+
+// funcs returns the helpers bound to this render
+func (rs *renderState) funcs() template.FuncMap {
+	c := rs.h.config
+	funcs := template.FuncMap{
+		"streamRange": func(n int, items any) (any, error) {
+			if !rs.stream {
+				return streamRange(nil, n, items)
+			}
+			return streamRange(rs.w, n, items)
+		},
+		"includeIfExists": rs.includeIfExists,
+		"lazy":            rs.lazy,
+		"joinTemplates":   rs.joinTemplates,
+		"load":            rs.load,
+		"once":            rs.once,
+		"capture":         rs.capture,
+		"captured":        rs.captured,
+		"partial": func(name string, data ...any) (template.HTML, error) {
+			out, err := rs.partial(name, data...)
+			return rs.isolating("partial", name, out, err)
+		},
+		"embed": func(name string, data any, layout ...string) (template.HTML, error) {
+			out, err := rs.embed(name, data, layout...)
+			return rs.isolating("embed", name, out, err)
+		},
+		"formatDate": func(layout string, t time.Time) string {
+			return formatDate(c.dateLayout(rs.lang, layout), t, rs.location())
+		},
+		"inTZ": func(t time.Time, name string) time.Time {
+			return c.inTZOr(t, name, rs.location())
+		},
+		"timeAgo": func(t time.Time) string {
+			return c.timeAgoIn(rs.lang, t, rs.location())
+		},
+		"humanBytes": func(n any, si ...bool) (string, error) {
+			return c.humanBytesIn(rs.lang, n, si...)
+		},
+		"humanDuration": func(v any) (string, error) {
+			return c.humanDurationIn(rs.lang, v)
+		},
+	}
+
+	if i := c.I18n; i != nil {
+		funcs["t"] = func(key string, args ...any) string {
+			return i.translateIn(rs.lang, key, args...)
+		}
+		funcs["tp"] = func(key string, values map[string]any) string {
+			return i.translateNamedIn(rs.lang, key, values)
+		}
+		// setLang switches the language of the rest of this render only
+		funcs["setLang"] = func(lang string) string {
+			rs.lang = lang
+			return ""
+		}
+		funcs["currentLang"] = func() string {
+			if rs.lang != "" {
+				return rs.lang
+			}
+			return i.CurrentLanguage()
+		}
+	}
+	funcs["formatNumber"] = func(v any, decimals ...int) (string, error) {
+		return c.formatNumberIn(rs.lang, v, decimals...)
+	}
+	funcs["formatCurrency"] = func(v any, symbol string) (string, error) {
+		return c.formatCurrencyIn(rs.lang, v, symbol)
+	}
+
+	if c.PanicTemplate != "" {
+		recoverFuncs(funcs)
+	}
+
+	if c.strictFuncs() || rs.strict {
+		all := rs.h.funcMap()
+		maps.Copy(all, funcs)
+		for name, fn := range all {
+			all[name] = rs.collectErrors(name, fn)
+		}
+		funcs = all
+	}
+
+	return funcs
+}
+
+// strictFuncs reports whether helper errors are collected instead of
+// aborting the render
+func (c *Config) strictFuncs() bool {
+	return c.Development && c.StrictFuncs
+}
+
+var errorType = reflect.TypeFor[error]()
+
+// collectErrors wraps a helper returning (value, error) so that a failure
+// is recorded with the helper and template name and the zero value is
+// returned in its place, letting the render carry on.
+func (rs *renderState) collectErrors(name string, fn any) any {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.NumOut() != 2 || t.Out(1) != errorType {
+		return fn
+	}
+
+	return reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+		var out []reflect.Value
+		if t.IsVariadic() {
+			out = v.CallSlice(args)
+		} else {
+			out = v.Call(args)
+		}
+
+		if err, _ := out[1].Interface().(error); err != nil {
+			rs.errs = append(rs.errs, fmt.Errorf("function %s in template %s: %w", name, rs.name, err))
+			return []reflect.Value{reflect.Zero(t.Out(0)), reflect.Zero(errorType)}
+		}
+		return out
+	}).Interface()
+}
+
+// renderSet is a clone of the template set with helpers bound to its own
+// renderState. Sets are pooled so the cost of cloning and escaping is paid
+// once per set rather than once per render.
+type renderSet struct {
+	t    *template.Template
+	rs   *renderState
+	pool *sync.Pool
+}
+
+// acquireSet returns a render set ready to execute name against w
+func (h *HTMLTemplate) acquireSet(w io.Writer, name string) (*renderSet, error) {
+	h.mu.RLock()
+	base, pool := h.base, h.sets
+	h.mu.RUnlock()
+
+	if s, ok := pool.Get().(*renderSet); ok {
+		s.rs.reset(w, name)
+		return s, nil
+	}
+
+	t, err := base.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &renderState{h: h, t: t, w: w, name: name}
+	t.Funcs(rs.funcs())
+	return &renderSet{t: t, rs: rs, pool: pool}, nil
+}
+
+// release returns the set to the pool it came from. Sets cloned before a
+// reload go back to the stale pool and are collected along with it.
+func (s *renderSet) release() {
+	s.rs.reset(nil, "")
+	s.pool.Put(s)
+}
+
+// execute renders name, using a private render set when a feature needs
+// per-render state and the shared template set otherwise
+func (h *HTMLTemplate) execute(ctx context.Context, w io.Writer, name string, data any) error {
+	h.mu.RLock()
+	t, raw, stateful := h.t, h.raw, h.stateful
+	h.mu.RUnlock()
+
+	if raw != nil && h.config.unescaped(name) {
+		return raw.ExecuteTemplate(w, name, data)
+	}
+
+	if stateful || h.config.strictFuncs() || needsState(ctx) {
+		return h.executeState(ctx, w, name, data)
+	}
+
+	return t.ExecuteTemplate(w, name, data)
+}
+
+// needsState reports whether ctx carries render options only a render set
+// applies: a language, a timezone or a trace
+func needsState(ctx context.Context) bool {
+	return langFrom(ctx) != "" || ctx.Value(locKey{}) != nil || ctx.Value(traceKey{}) != nil
+}
+
+// executeState renders name on a private render set
+func (h *HTMLTemplate) executeState(ctx context.Context, w io.Writer, name string, data any) error {
+	s, err := h.acquireSet(w, name)
+	if err != nil {
+		return err
+	}
+	defer s.release()
+
+	s.rs.lang = langFrom(ctx)
+	s.rs.loc, _ = ctx.Value(locKey{}).(*time.Location)
+	s.rs.trace, _ = ctx.Value(traceKey{}).(*Trace)
+	return s.run(ctx, name, data, name)
+}
+
+// run executes name with data once the data loaders used by the templates
+// in uses have been resolved
+func (s *renderSet) run(ctx context.Context, name string, data any, uses ...string) error {
+	if err := s.rs.preload(ctx, uses...); err != nil {
+		return err
+	}
+	if err := s.t.ExecuteTemplate(s.rs.w, name, data); err != nil {
+		return s.rs.result(err)
+	}
+	return s.rs.result(s.rs.flushLazy())
+}
+
+// renderLayout renders view inside layout on a fresh clone of the template
+// set bound to rs. Without a layout the view is rendered on its own.
+func (h *HTMLTemplate) renderLayout(w io.Writer, layout, view string, data any, rs *renderState) error {
+	tpl, _, err := h.wireLayout(layout, view)
+	if err != nil {
+		return err
+	}
+
+	name := view
+	if layout != "" {
+		name = layout
+	}
+
+	tpl.Funcs(rs.funcs())
+
+	outer := rs.t
+	rs.t = tpl
+	defer func() { rs.t = outer }()
+
+	if err := tpl.ExecuteTemplate(w, name, data); err != nil {
+		return err
+	}
+
+	// The outermost render flushes the deferred partials
+	if outer == nil {
+		return rs.flushLazy()
+	}
+	return nil
+}
+
+// wireLayout returns a clone of the template set whose content block
+// renders view. Without a layout the clone is returned as is.
+//
+// A view file that defines its own content block keeps it: the layout
+// renders that block, whatever other files define "content", and the
+// view's top-level text is ignored. Otherwise any content block in the set,
+// including a default one in the layout, is replaced by the view.
+//
+// It also returns the generation of the template set it cloned, see cfgGen.
+func (h *HTMLTemplate) wireLayout(layout, view string) (*template.Template, uint64, error) {
+	h.mu.RLock()
+	tpl, err := h.base.Clone()
+	own, gen := h.contents[view], h.cfgGen
+	h.mu.RUnlock()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if layout != "" && own != nil {
+		if _, err := tpl.AddParseTree("content", own.Copy()); err != nil {
+			return nil, 0, err
+		}
+	} else if layout != "" {
+		// Define the content block
+		l, r := h.config.Delimiters[0], h.config.Delimiters[1]
+		src := l + `define "content"` + r + l + `template "` + view + `" .` + r + l + `end` + r
+		if _, err := tpl.New("content").Parse(src); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return tpl, gen, nil
+}
+
+// partial renders the named template with data and returns the result.
+// Several data arguments are merged as by mergeData, later ones winning.
+// Within one render, calls with the same name and deeply equal data render
+// once and reuse the output; see WithNoMemo for the partials that don't.
+func (rs *renderState) partial(name string, data ...any) (template.HTML, error) {
+	name = rs.h.resolve(rs.relative(name))
+	if err := rs.enter("partial", name); err != nil {
+		return "", err
+	}
+	defer rs.leave()
+
+	var dot any
+	switch len(data) {
+	case 0:
+	case 1:
+		dot = data[0]
+	default:
+		merged, err := mergeData(data...)
+		if err != nil {
+			return "", err
+		}
+		dot = merged
+	}
+
+	memo := !slices.Contains(rs.h.config.NoMemo, name) && !rs.h.hasSideEffects(name) && memoizable(reflect.ValueOf(dot))
+	if memo {
+		if out, ok := rs.memoized(name, dot); ok {
+			return out, nil
+		}
+	}
+	key := dot
+
+	dot, err := rs.h.config.transform(name, dot)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := rs.t.ExecuteTemplate(&buf, name, dot); err != nil {
+		return "", err
+	}
+
+	out := template.HTML(buf.String())
+	if memo {
+		rs.memo[name] = append(rs.memo[name], memoEntry{data: key, lang: rs.lang, loc: rs.loc, out: out})
+	}
+	return out, nil
+}
+
+// memoEntry is the output of one partial call, kept for the rest of the
+// render. The language and timezone are part of the key, as setLang
+// changes them mid-render.
+type memoEntry struct {
+	data any
+	lang string
+	loc  *time.Location
+	out  template.HTML
+}
+
+// memoized returns the output of an earlier call of the named partial with
+// equal data, in the same language and timezone, in this render
+func (rs *renderState) memoized(name string, data any) (template.HTML, bool) {
+	if rs.memo == nil {
+		rs.memo = map[string][]memoEntry{}
+	}
+	for _, m := range rs.memo[name] {
+		if m.lang == rs.lang && m.loc == rs.loc && reflect.DeepEqual(m.data, data) {
+			return m.out, true
+		}
+	}
+	return "", false
+}
+
+// sideEffectFuncs are the helpers whose effect on the render a reused
+// partial output would skip
+var sideEffectFuncs = []string{"setLang", "once", "capture", "captured", "lazy"}
+
+// sideEffects returns the templates in t that call a side effect helper,
+// or include a template by a name known only at render time, directly or
+// through the templates they reference
+func sideEffects(t *template.Template, deps map[string][]string) map[string]bool {
+	direct := map[string]bool{}
+	for _, tpl := range t.Templates() {
+		if tpl.Tree == nil {
+			continue
+		}
+		walkTree(tpl.Tree.Root, func(node parse.Node) bool {
+			switch n := node.(type) {
+			case *parse.IdentifierNode:
+				if slices.Contains(sideEffectFuncs, n.Ident) {
+					direct[tpl.Name()] = true
+				}
+			case *parse.CommandNode:
+				fn, ok := n.Args[0].(*parse.IdentifierNode)
+				if !ok || !slices.Contains(includeFuncs, fn.Ident) {
+					break
+				}
+				if len(n.Args) < 2 {
+					direct[tpl.Name()] = true
+				} else if _, literal := n.Args[1].(*parse.StringNode); !literal {
+					direct[tpl.Name()] = true
+				}
+			}
+			return !direct[tpl.Name()]
+		})
+	}
+
+	effects := map[string]bool{}
+	for name := range deps {
+		effects[name] = slices.ContainsFunc(reachable(deps, name), func(ref string) bool { return direct[ref] })
+	}
+	return effects
+}
+
+// hasSideEffects reports whether the named template reaches a side effect
+// helper, see sideEffects
+func (h *HTMLTemplate) hasSideEffects(name string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.effects[name]
+}
+
+// memoizable reports whether v holds plain values only, so that deep
+// equality with an earlier call's data means the partial sees the same
+// data. Pointers, maps, slices and the like may have changed since.
+func memoizable(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid, reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	case reflect.Interface:
+		return v.IsNil() || memoizable(v.Elem())
+	case reflect.Array:
+		for i := range v.Len() {
+			if !memoizable(v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if !memoizable(v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// includeIfExists renders the named template like partial when it is
+// defined and returns nothing otherwise, for optional extension points
+// such as a per-page head snippet
+func (rs *renderState) includeIfExists(name string, data ...any) (template.HTML, error) {
+	name = rs.relative(name)
+	if rs.t.Lookup(name) == nil {
+		return "", nil
+	}
+	return rs.partial(name, data...)
+}
+
+// joinTemplates renders the named template once per item of a slice or
+// array and joins the results with sep. When there are no items it returns
+// empty instead, if given. sep and empty are escaped unless they are
+// already template.HTML.
+func (rs *renderState) joinTemplates(name string, items any, sep any, empty ...any) (template.HTML, error) {
+	v := reflect.ValueOf(items)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	n := 0
+	switch v.Kind() {
+	case reflect.Invalid:
+	case reflect.Slice, reflect.Array:
+		n = v.Len()
+	default:
+		return "", fmt.Errorf("joinTemplates: can't iterate over %s", v.Type())
+	}
+
+	if n == 0 {
+		if len(empty) > 0 {
+			return htmlOf(empty[0]), nil
+		}
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	for i := range n {
+		if i > 0 {
+			buf.WriteString(string(htmlOf(sep)))
+		}
+		out, err := rs.partial(name, v.Index(i).Interface())
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(string(out))
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// htmlOf returns v as HTML, escaping it unless it is template.HTML
+func htmlOf(v any) template.HTML {
+	if h, ok := v.(template.HTML); ok {
+		return h
+	}
+	return template.HTML(template.HTMLEscapeString(fmt.Sprint(v)))
+}
+
+// usesFuncs reports whether any template in t calls one of the functions
+func usesFuncs(t *template.Template, funcs ...string) bool {
+	found := false
+	for _, tpl := range t.Templates() {
+		if tpl.Tree == nil {
+			continue
+		}
+		walkTree(tpl.Tree.Root, func(node parse.Node) bool {
+			if n, ok := node.(*parse.IdentifierNode); ok && slices.Contains(funcs, n.Ident) {
+				found = true
+			}
+			return !found
+		})
+		if found {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,235 @@
+package html
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// counter returns a template function counting its calls, and the count
+func counter() (template.FuncMap, *int) {
+	n := 0
+	return template.FuncMap{"count": func() int { n++; return n }}, &n
+}
+
+func TestPartialMemo(t *testing.T) {
+	funcs, calls := counter()
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{partial "card.html" "a"}}{{partial "card.html" "a"}}{{partial "card.html" "b"}}`,
+		"card.html": `[{{.}}{{count}}]`,
+	}, WithFuncs(funcs))
+
+	if got := renderString(t, h, "page.html", nil); got != "[a1][a1][b2]" {
+		t.Fatalf("got %q", got)
+	}
+	if *calls != 2 {
+		t.Fatalf("card executed %d times, want once per distinct data", *calls)
+	}
+
+	// The memo lasts one render
+	if got := renderString(t, h, "page.html", nil); got != "[a3][a3][b4]" {
+		t.Fatalf("second render got %q", got)
+	}
+}
+
+func TestPartialMemoNoMemo(t *testing.T) {
+	funcs, calls := counter()
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{partial "card.html" "a"}}{{partial "card.html" "a"}}`,
+		"card.html": `[{{count}}]`,
+	}, WithFuncs(funcs), WithNoMemo("card.html"))
+
+	if got := renderString(t, h, "page.html", nil); got != "[1][2]" {
+		t.Fatalf("got %q", got)
+	}
+	if *calls != 2 {
+		t.Fatalf("card executed %d times, want 2", *calls)
+	}
+}
+
+type memoItem struct {
+	Name string
+}
+
+func TestPartialMemoMutatedData(t *testing.T) {
+	funcs := template.FuncMap{"rename": func(i *memoItem, name string) string { i.Name = name; return "" }}
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{partial "card.html" .Item}}{{rename .Item "b"}}{{partial "card.html" .Item}}` +
+			`{{partial "list.html" .List}}{{rename (index .List 0) "d"}}{{partial "list.html" .List}}`,
+		"card.html": `[{{.Name}}]`,
+		"list.html": `[{{range .}}{{.Name}}{{end}}]`,
+	}, WithFuncs(funcs))
+
+	data := struct {
+		Item *memoItem
+		List []*memoItem
+	}{&memoItem{Name: "a"}, []*memoItem{{Name: "c"}}}
+	if got := renderString(t, h, "page.html", data); got != "[a][b][c][d]" {
+		t.Fatalf("got %q, want output of the mutated data", got)
+	}
+}
+
+func TestPartialMemoSideEffects(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{partial "switch.html" "de"}}{{t "hello"}} {{partial "switch.html" "en"}}{{t "hello"}} ` +
+			`{{partial "switch.html" "de"}}{{t "hello"}} {{partial "wrap.html" "en"}}{{t "hello"}} ` +
+			`{{partial "item.html" "de"}}{{partial "wrap.html" "de"}}{{t "hello"}}`,
+		"switch.html": `{{setLang .}}`,
+		"wrap.html":   `{{partial "item.html" .}}{{partial "switch.html" .}}`,
+		"item.html":   `[{{.}}]`,
+	}, WithI18n("en", testTranslations))
+
+	want := "Hallo Hello Hallo [en]Hello [de][de]Hallo"
+	if got := renderString(t, h, "page.html", nil); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if !h.effects["wrap.html"] || h.effects["item.html"] {
+		t.Fatalf("effects %v: want wrap.html through switch.html, not item.html", h.effects)
+	}
+}
+
+func TestPartialMemoLanguage(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":  `{{partial "greet.html" "x"}}{{setLang "de"}}{{partial "greet.html" "x"}}`,
+		"greet.html": `{{t "hello"}}|`,
+	}, WithI18n("en", testTranslations))
+
+	if got := renderString(t, h, "page.html", nil); got != "Hello|Hallo|" {
+		t.Fatalf("got %q, want the second call in de", got)
+	}
+}
+
+func TestMemoizable(t *testing.T) {
+	n := 1
+	tests := []struct {
+		v    any
+		want bool
+	}{
+		{nil, true},
+		{"a", true},
+		{3.5, true},
+		{memoItem{Name: "a"}, true},
+		{[2]string{"a", "b"}, true},
+		{&memoItem{}, false},
+		{&n, false},
+		{[]string{"a"}, false},
+		{map[string]any{"a": 1}, false},
+		{struct{ Items []int }{}, false},
+	}
+	for _, tt := range tests {
+		if got := memoizable(reflect.ValueOf(tt.v)); got != tt.want {
+			t.Errorf("memoizable(%#v) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestStrictFuncsCollectErrors(t *testing.T) {
+	captureLogs(t)
+	funcs := template.FuncMap{"check": func(ok bool) (string, error) {
+		if !ok {
+			return "", errors.New("boom")
+		}
+		return "ok", nil
+	}}
+	files := map[string]string{"page.html": `[{{check false}}|{{check true}}|{{check false}}]`}
+
+	h := newTestEngine(t, files, WithFuncs(funcs), WithDevelopment(true), WithStrictFuncs(true))
+	var buf bytes.Buffer
+	err := h.Render(&buf, "page.html", nil)
+	if buf.String() != "[|ok|]" {
+		t.Fatalf("got %q, want the render to carry on", buf.String())
+	}
+	if err == nil || strings.Count(err.Error(), "function check in template page.html: boom") != 2 {
+		t.Fatalf("got %v, want both errors annotated", err)
+	}
+
+	// Outside development mode the first error aborts the render
+	h = newTestEngine(t, files, WithFuncs(funcs), WithStrictFuncs(true))
+	buf.Reset()
+	if err := h.Render(&buf, "page.html", nil); err == nil || buf.String() != "[" {
+		t.Fatalf("got %q, %v, want the render to stop", buf.String(), err)
+	}
+}
+
+func TestPartialDepth(t *testing.T) {
+	files := map[string]string{
+		"tree.html": `({{.N}}{{range .Kids}}{{partial "tree.html" .}}{{end}})`,
+		"loop.html": `{{partial "loop.html" .}}`,
+	}
+	type node struct {
+		N    int
+		Kids []node
+	}
+	h := newTestEngine(t, files, WithMaxRenderDepth(3))
+	tree := node{1, []node{{2, []node{{3, nil}}}, {4, nil}}}
+	if got := renderString(t, h, "tree.html", tree); got != "(1(2(3))(4))" {
+		t.Fatalf("got %q", got)
+	}
+
+	var buf bytes.Buffer
+	err := h.Render(&buf, "loop.html", nil)
+	if err == nil || !strings.Contains(err.Error(), "partial loop.html: max render depth 3 exceeded") {
+		t.Fatalf("got %v, want the depth limit", err)
+	}
+}
+
+func TestJoinTemplates(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"list.html": `{{joinTemplates "tag.html" .Tags ", " "<none>"}}|{{joinTemplates "tag.html" .Tags (safeHTML "<br>")}}`,
+		"tag.html":  `<i>{{.}}</i>`,
+		"bad.html":  `{{joinTemplates "tag.html" 42 ","}}`,
+	})
+	tests := []struct {
+		tags any
+		want string
+	}{
+		{[]string{"a", "b"}, "<i>a</i>, <i>b</i>|<i>a</i><br><i>b</i>"},
+		{[1]int{7}, "<i>7</i>|<i>7</i>"},
+		{[]string{}, "&lt;none&gt;|"},
+		{nil, "&lt;none&gt;|"},
+	}
+	for _, tt := range tests {
+		if got := renderString(t, h, "list.html", map[string]any{"Tags": tt.tags}); got != tt.want {
+			t.Errorf("%v: got %q, want %q", tt.tags, got, tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := h.Render(&buf, "bad.html", nil); err == nil || !strings.Contains(err.Error(), "can't iterate over int") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestIncludeIfExists(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":  `[{{includeIfExists "promo.html" .}}][{{includeIfExists "missing.html" .}}]`,
+		"promo.html": `promo {{.}}`,
+	})
+	if got := renderString(t, h, "page.html", "x"); got != "[promo x][]" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestSlowRenderThreshold(t *testing.T) {
+	logs := captureLogs(t)
+	h := newTestEngine(t, map[string]string{
+		"slow.html": `{{sleep}}slow`,
+		"fast.html": `fast`,
+	}, WithSlowRenderThreshold(50*time.Millisecond),
+		WithFuncs(template.FuncMap{"sleep": func() string { time.Sleep(100 * time.Millisecond); return "" }}))
+
+	renderString(t, h, "fast.html", nil)
+	if logs.Len() != 0 {
+		t.Fatalf("fast render logged %q", logs.String())
+	}
+	if got := renderString(t, h, "slow.html", nil); got != "slow" {
+		t.Fatalf("got %q", got)
+	}
+	if !strings.Contains(logs.String(), "Slow render: template slow.html took ") || !strings.Contains(logs.String(), "(threshold 50ms)") {
+		t.Fatalf("no slow render warning in %q", logs.String())
+	}
+}
@@ -0,0 +1,66 @@
+package html
+
+import (
+	stdhtml "html"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	h := newTestEngine(t, map[string]string{"page.html": ``})
+
+	w := httptest.NewRecorder()
+	h.SecurityHeaders(w, "abc")
+	csp := w.Header().Get("Content-Security-Policy")
+	if !strings.Contains(csp, "script-src 'self' 'nonce-abc'") || strings.Contains(csp, "{nonce}") {
+		t.Fatalf("CSP %q", csp)
+	}
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" || w.Header().Get("Referrer-Policy") == "" {
+		t.Fatalf("headers %v", w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	h.SecurityHeaders(w, "")
+	if csp := w.Header().Get("Content-Security-Policy"); strings.Contains(csp, "nonce") {
+		t.Fatalf("CSP without nonce %q", csp)
+	}
+}
+
+func TestRenderHTTPNonce(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `<script nonce="{{.CSPNonce}}"></script>`,
+	}, WithCSP("script-src 'nonce-{nonce}'"))
+
+	nonces := map[string]bool{}
+	for range 2 {
+		w := httptest.NewRecorder()
+		if err := h.RenderHTTP(w, httptest.NewRequest("GET", "/", nil), "page.html", nil); err != nil {
+			t.Fatal(err)
+		}
+		csp := w.Header().Get("Content-Security-Policy")
+		nonce := strings.TrimSuffix(strings.TrimPrefix(csp, "script-src 'nonce-"), "'")
+		if nonce == "" || stdhtml.UnescapeString(w.Body.String()) != `<script nonce="`+nonce+`"></script>` {
+			t.Fatalf("CSP %q, body %q", csp, w.Body.String())
+		}
+		nonces[nonce] = true
+	}
+	if len(nonces) != 2 {
+		t.Fatal("nonce reused across responses")
+	}
+}
+
+func TestRenderHTTPNonceStructData(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html": `<h1>{{.Title}}</h1>{{if .CSPNonce}}nonce{{end}}`,
+	}, WithCSP(""))
+
+	w := httptest.NewRecorder()
+	data := struct{ Title string }{"Home"}
+	if err := h.RenderHTTP(w, httptest.NewRequest("GET", "/", nil), "page.html", data); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != "<h1>Home</h1>nonce" {
+		t.Fatalf("got %q, want the struct fields beside the nonce", got)
+	}
+}
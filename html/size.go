@@ -0,0 +1,105 @@
+package html
+
+import (
+	"io"
+	"math"
+	"sync/atomic"
+)
+
+// SizeStats summarizes the sizes in bytes of a template's successful
+// renders since the engine was created
+type SizeStats struct {
+	Renders int64
+	Min     int64
+	Max     int64
+	Total   int64
+}
+
+// Avg returns the mean render size, zero before the first render
+func (s SizeStats) Avg() float64 {
+	if s.Renders == 0 {
+		return 0
+	}
+	return float64(s.Total) / float64(s.Renders)
+}
+
+// sizeCounter accumulates SizeStats without locking
+type sizeCounter struct {
+	renders, total atomic.Int64
+	min, max       atomic.Int64
+}
+
+// newSizeCounter returns a counter with no renders
+func newSizeCounter() *sizeCounter {
+	c := &sizeCounter{}
+	c.min.Store(math.MaxInt64)
+	return c
+}
+
+// add records a render of n bytes
+func (c *sizeCounter) add(n int64) {
+	c.renders.Add(1)
+	c.total.Add(n)
+	for m := c.min.Load(); n < m && !c.min.CompareAndSwap(m, n); m = c.min.Load() {
+	}
+	for m := c.max.Load(); n > m && !c.max.CompareAndSwap(m, n); m = c.max.Load() {
+	}
+}
+
+// stats returns a snapshot of c. Renders in flight may be reflected in
+// some fields and not yet in others.
+func (c *sizeCounter) stats() SizeStats {
+	s := SizeStats{
+		Renders: c.renders.Load(),
+		Min:     c.min.Load(),
+		Max:     c.max.Load(),
+		Total:   c.total.Load(),
+	}
+	if s.Min == math.MaxInt64 {
+		s.Min = 0
+	}
+	return s
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// countOutput returns w wrapped to count what is written and a function
+// that records the count under name, when size stats are on. Otherwise w
+// is returned as is and the function does nothing.
+func (h *HTMLTemplate) countOutput(name string, w io.Writer) (io.Writer, func()) {
+	if !h.config.SizeStats {
+		return w, func() {}
+	}
+
+	cw := &countingWriter{w: w}
+	return cw, func() {
+		c, ok := h.sizes.Load(name)
+		if !ok {
+			c, _ = h.sizes.LoadOrStore(name, newSizeCounter())
+		}
+		c.(*sizeCounter).add(cw.n)
+	}
+}
+
+// SizeStats returns the output size statistics of each template rendered
+// by Render, RenderContext or RenderWithLayout since the engine was
+// created, keyed by template name, or by view for layout renders. It is
+// empty unless WithSizeStats is on.
+func (h *HTMLTemplate) SizeStats() map[string]SizeStats {
+	stats := map[string]SizeStats{}
+	h.sizes.Range(func(name, c any) bool {
+		stats[name.(string)] = c.(*sizeCounter).stats()
+		return true
+	})
+	return stats
+}
@@ -0,0 +1,66 @@
+package html
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSizeStats(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"rows.html":         `{{range .}}<li>{{.}}</li>{{end}}`,
+		"page.html":         `page`,
+		"bad.html":          `bad{{index . 5}}`,
+		"layouts/base.html": `<main>{{template "content" .}}</main>`,
+	}, WithSizeStats(true), WithLayoutDir("layouts"))
+
+	if got := h.SizeStats(); len(got) != 0 {
+		t.Fatalf("stats before any render: %v", got)
+	}
+
+	for _, n := range []int{1, 10, 4} {
+		renderString(t, h, "rows.html", strings.Split(strings.Repeat("x", n), ""))
+	}
+	if err := h.RenderWithLayout(&bytes.Buffer{}, &RenderData{View: "page.html", Layout: "base"}); err != nil {
+		t.Fatal(err)
+	}
+	// Failed renders are not counted
+	if err := h.Render(&bytes.Buffer{}, "bad.html", nil); err == nil {
+		t.Fatal("bad.html: want an error")
+	}
+
+	stats := h.SizeStats()
+	if _, ok := stats["bad.html"]; ok {
+		t.Fatal("failed render counted")
+	}
+	rows := stats["rows.html"]
+	if rows != (SizeStats{Renders: 3, Min: 10, Max: 100, Total: 150}) || rows.Avg() != 50 {
+		t.Fatalf("rows.html got %+v", rows)
+	}
+	if page := stats["page.html"]; page.Renders != 1 || page.Total != int64(len("<main>page</main>")) {
+		t.Fatalf("page.html got %+v", page)
+	}
+}
+
+func TestSizeStatsConcurrent(t *testing.T) {
+	h := newTestEngine(t, map[string]string{"page.html": `{{.}}`}, WithSizeStats(true))
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() {
+			renderString(t, h, "page.html", strings.Repeat("x", i+1))
+		})
+	}
+	wg.Wait()
+
+	if got := h.SizeStats()["page.html"]; got != (SizeStats{Renders: 50, Min: 1, Max: 50, Total: 1275}) {
+		t.Fatalf("got %+v", got)
+	}
+
+	h = newTestEngine(t, map[string]string{"page.html": `page`})
+	renderString(t, h, "page.html", nil)
+	if got := h.SizeStats(); len(got) != 0 {
+		t.Fatalf("stats without WithSizeStats: %v", got)
+	}
+}
@@ -0,0 +1,66 @@
+package html
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"slices"
+)
+
+// Snapshot is the immutable output of one render, written out as many
+// times as needed without rendering again
+type Snapshot struct {
+	body []byte
+	etag string
+}
+
+// RenderSnapshot renders a template once and returns its output as a
+// Snapshot, for pages served identically to many clients. Caching the
+// snapshot is up to the caller.
+func (h *HTMLTemplate) RenderSnapshot(name string, data any) (*Snapshot, error) {
+	var buf bytes.Buffer
+	if err := h.Render(&buf, name, data); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	return &Snapshot{
+		body: buf.Bytes(),
+		etag: `"` + hex.EncodeToString(sum[:8]) + `"`,
+	}, nil
+}
+
+// WriteTo writes the snapshot to w. It is safe to call concurrently.
+func (s *Snapshot) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(s.body)
+	return int64(n), err
+}
+
+// Bytes returns a copy of the rendered output
+func (s *Snapshot) Bytes() []byte {
+	return slices.Clone(s.body)
+}
+
+// Len returns the size of the rendered output in bytes
+func (s *Snapshot) Len() int {
+	return len(s.body)
+}
+
+// ETag returns a strong entity tag derived from the output, quoted for use
+// in an ETag header
+func (s *Snapshot) ETag() string {
+	return s.etag
+}
+
+// RenderHash renders a template through a SHA-256 hash and returns the hex
+// digest of the output, for cache keys and change detection. The output is
+// streamed into the hash rather than kept, unless the output mode buffers
+// the page; see OutputMode.
+func (h *HTMLTemplate) RenderHash(name string, data any) (string, error) {
+	sum := sha256.New()
+	if err := h.Render(sum, name, data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
@@ -0,0 +1,71 @@
+package html
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRenderSnapshot(t *testing.T) {
+	h := newTestEngine(t, map[string]string{"page.html": `<p>{{.}}</p>`})
+	s, err := h.RenderSnapshot("page.html", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Len() != 8 || string(s.Bytes()) != "<p>a</p>" {
+		t.Fatalf("got %q", s.Bytes())
+	}
+	s.Bytes()[0] = 'X'
+	if string(s.Bytes()) != "<p>a</p>" {
+		t.Fatal("Bytes exposes the snapshot's buffer")
+	}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			var buf bytes.Buffer
+			if n, err := s.WriteTo(&buf); err != nil || n != 8 || buf.String() != "<p>a</p>" {
+				t.Errorf("WriteTo got %q, %d, %v", buf.String(), n, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	other, err := h.RenderSnapshot("page.html", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := h.RenderSnapshot("page.html", "a")
+	if s.ETag() != again.ETag() || s.ETag() == other.ETag() || !strings.HasPrefix(s.ETag(), `"`) {
+		t.Fatalf("ETags %s %s %s", s.ETag(), again.ETag(), other.ETag())
+	}
+
+	if _, err := h.RenderSnapshot("missing.html", nil); err == nil {
+		t.Fatal("missing template: want an error")
+	}
+}
+
+func TestRenderHash(t *testing.T) {
+	h := newTestEngine(t, map[string]string{"page.html": `<p>{{.}}</p>`})
+
+	a, err := h.RenderHash("page.html", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := h.RenderHash("page.html", "a")
+	other, _ := h.RenderHash("page.html", "b")
+	if a != again || a == other {
+		t.Fatalf("hashes %s %s %s", a, again, other)
+	}
+
+	sum := sha256.Sum256([]byte("<p>a</p>"))
+	if want := hex.EncodeToString(sum[:]); a != want {
+		t.Fatalf("got %s, want %s", a, want)
+	}
+	if _, err := h.RenderHash("missing.html", nil); err == nil {
+		t.Fatal("missing template: want an error")
+	}
+}
@@ -0,0 +1,45 @@
+package html
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// RenderSSE renders a template and writes it to w as one server-sent
+// event, for HTMX SSE swaps and other server-push UIs. The output is split
+// into one data: line per line, so clients reassemble it exactly. event
+// names the event type; "" sends an unnamed message. w is flushed after
+// the event when it implements http.Flusher. Nothing is written if the
+// render fails.
+func (h *HTMLTemplate) RenderSSE(w io.Writer, event, name string, data any) error {
+	if strings.ContainsAny(event, "\r\n") {
+		return errors.New("sse event name must not contain line breaks")
+	}
+
+	var buf bytes.Buffer
+	if err := h.Render(&buf, name, data); err != nil {
+		return err
+	}
+
+	var msg strings.Builder
+	if event != "" {
+		msg.WriteString("event: " + event + "\n")
+	}
+	body := strings.ReplaceAll(buf.String(), "\r\n", "\n")
+	body = strings.ReplaceAll(body, "\r", "\n")
+	for line := range strings.SplitSeq(body, "\n") {
+		msg.WriteString("data: " + line + "\n")
+	}
+	msg.WriteString("\n")
+
+	if _, err := io.WriteString(w, msg.String()); err != nil {
+		return err
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package html
+
+import "testing"
+
+func TestRenderSSE(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"row.html": "<tr>\r\n<td>{{.}}</td>\r</tr>",
+		"bad.html": `{{.Missing.Field}}`,
+	})
+
+	var w flushRecorder
+	if err := h.RenderSSE(&w, "row", "row.html", "x"); err != nil {
+		t.Fatal(err)
+	}
+	want := "event: row\ndata: <tr>\ndata: <td>x</td>\ndata: </tr>\n\n"
+	if w.String() != want || len(w.flushed) != 1 {
+		t.Fatalf("got %q flushed %d times, want %q flushed once", w.String(), len(w.flushed), want)
+	}
+
+	w.Reset()
+	if err := h.RenderSSE(&w, "", "row.html", "y"); err != nil {
+		t.Fatal(err)
+	}
+	if w.String()[:6] != "data: " {
+		t.Fatalf("unnamed event got %q", w.String())
+	}
+
+	w.Reset()
+	if err := h.RenderSSE(&w, "a\nb", "row.html", nil); err == nil {
+		t.Fatal("event with a line break: want an error")
+	}
+	if err := h.RenderSSE(&w, "row", "bad.html", 1); err == nil || w.Len() != 0 {
+		t.Fatalf("failed render got %q, %v, want nothing written", w.String(), err)
+	}
+}
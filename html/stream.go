@@ -0,0 +1,150 @@
+package html
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"reflect"
+	"slices"
+)
+
+// RenderStream renders a template, flushing after each streamRange batch
+// when w is an http.Flusher. After the first flush an error can only
+// truncate the page, so keep streamRange loops in plain element content.
+func (h *HTMLTemplate) RenderStream(w io.Writer, name string, data any) error {
+	if h.config.Development {
+		if err := h.reloadIfNeeded(); err != nil {
+			return fmt.Errorf("failed to reload templates: %w", err)
+		}
+	}
+
+	name = h.resolve(name)
+	if err := h.validateTemplate(name); err != nil {
+		return err
+	}
+
+	data, err := h.config.transform(name, data)
+	if err != nil {
+		return err
+	}
+	if data, err = h.config.prepare(name, data); err != nil {
+		return err
+	}
+
+	set, err := h.acquireSet(w, name)
+	if err != nil {
+		return err
+	}
+	defer set.release()
+
+	set.rs.stream = true
+	if err := set.run(context.Background(), name, data, name); err != nil {
+		return err
+	}
+
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// streamRange iterates over a slice, array or map and flushes w after every
+// n items when it implements http.Flusher. Outside RenderStream it behaves
+// like a plain range. Maps yield their keys and values in key order, as
+// {{range $key, $value := streamRange 50 .Totals}}.
+func streamRange(w io.Writer, n int, items any) (any, error) {
+	if n <= 0 {
+		return nil, errors.New("streamRange: batch size must be positive")
+	}
+
+	v := reflect.ValueOf(items)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	// The template has finished the previous body by the time yield
+	// returns, so the flush happens between rows.
+	flusher, _ := w.(http.Flusher)
+	flush := func(i int) {
+		if flusher != nil && i > 0 && i%n == 0 {
+			flusher.Flush()
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		return iter.Seq[any](func(func(any) bool) {}), nil
+	case reflect.Slice, reflect.Array:
+		return iter.Seq[any](func(yield func(any) bool) {
+			for i := range v.Len() {
+				flush(i)
+				if !yield(v.Index(i).Interface()) {
+					return
+				}
+			}
+		}), nil
+	case reflect.Map:
+		keys := v.MapKeys()
+		slices.SortFunc(keys, compareKeys)
+		return iter.Seq2[any, any](func(yield func(any, any) bool) {
+			for i, key := range keys {
+				flush(i)
+				if !yield(key.Interface(), v.MapIndex(key).Interface()) {
+					return
+				}
+			}
+		}), nil
+	}
+	return nil, fmt.Errorf("streamRange: can't iterate over %s", v.Type())
+}
+
+// compareKeys orders map keys as range does: numbers and strings by value,
+// false before true, and other keys by their printed form
+func compareKeys(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	case reflect.Bool:
+		if a.Bool() == b.Bool() {
+			return 0
+		}
+		if b.Bool() {
+			return -1
+		}
+		return 1
+	}
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// RenderReader renders a template in the background and returns a reader
+// of its output, for piping a page into uploads, hashes or chunked
+// transfers without holding all of it in memory. A render error is
+// returned by Read once the output before it has been read. Closing the
+// reader early makes the render's next write fail, which stops it.
+func (h *HTMLTemplate) RenderReader(name string, data any) (io.ReadCloser, error) {
+	if h.config.Development {
+		if err := h.reloadIfNeeded(); err != nil {
+			return nil, fmt.Errorf("failed to reload templates: %w", err)
+		}
+	}
+	name = h.resolve(name)
+	if err := h.validateTemplate(name); err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(h.Render(pw, name, data))
+	}()
+	return pr, nil
+}
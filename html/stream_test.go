@@ -0,0 +1,125 @@
+package html
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// flushRecorder records the output written before each flush
+type flushRecorder struct {
+	bytes.Buffer
+	flushed []string
+}
+
+func (f *flushRecorder) Flush() {
+	f.flushed = append(f.flushed, f.String())
+}
+
+func TestRenderStreamFlushes(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"rows.html": `<ul>{{range streamRange 2 .}}<li>{{.}}</li>{{end}}</ul>`,
+	})
+
+	var w flushRecorder
+	if err := h.RenderStream(&w, "rows.html", []int{1, 2, 3, 4, 5}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"<ul><li>1</li><li>2</li>",
+		"<ul><li>1</li><li>2</li><li>3</li><li>4</li>",
+		"<ul><li>1</li><li>2</li><li>3</li><li>4</li><li>5</li></ul>",
+	}
+	if strings.Join(w.flushed, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("flushed %q, want %q", w.flushed, want)
+	}
+}
+
+func TestStreamRangeWithoutStream(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"rows.html": `{{range streamRange 1 .}}[{{.}}]{{end}}`,
+	})
+
+	var w flushRecorder
+	if err := h.Render(&w, "rows.html", [3]string{"a", "b", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if w.String() != "[a][b][c]" {
+		t.Fatalf("got %q", w.String())
+	}
+	if len(w.flushed) != 0 {
+		t.Fatalf("flushed %d times outside RenderStream", len(w.flushed))
+	}
+}
+
+func TestStreamRangeMap(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"totals.html": `{{range $k, $v := streamRange 2 .}}{{$k}}={{$v}};{{end}}`,
+	})
+
+	data := map[string]int{"c": 3, "a": 1, "d": 4, "b": 2}
+	for range 5 {
+		var w flushRecorder
+		if err := h.RenderStream(&w, "totals.html", data); err != nil {
+			t.Fatal(err)
+		}
+		if w.String() != "a=1;b=2;c=3;d=4;" {
+			t.Fatalf("got %q, want all pairs in key order", w.String())
+		}
+		if len(w.flushed) != 2 || w.flushed[0] != "a=1;b=2;" {
+			t.Fatalf("flushed %q", w.flushed)
+		}
+	}
+
+	h = newTestEngine(t, map[string]string{
+		"ids.html": `{{range $k, $v := streamRange 10 .}}{{$k}}{{end}}`,
+	})
+	if got := renderString(t, h, "ids.html", map[int]bool{10: true, 2: true, -1: true}); got != "-1210" {
+		t.Fatalf("int keys got %q, want numeric order", got)
+	}
+}
+
+func TestStreamRangeErrors(t *testing.T) {
+	if _, err := streamRange(io.Discard, 0, []int{1}); err == nil {
+		t.Error("batch size 0: want an error")
+	}
+	if _, err := streamRange(io.Discard, 1, 42); err == nil {
+		t.Error("int items: want an error")
+	}
+	if _, err := streamRange(io.Discard, 1, nil); err != nil {
+		t.Errorf("nil items: %v", err)
+	}
+}
+
+func TestRenderReader(t *testing.T) {
+	captureLogs(t)
+	h := newTestEngine(t, map[string]string{"page.html": `page {{.}}`},
+		WithDevelopment(true), WithAlias("old.html", "page.html"))
+
+	read := func(name string) string {
+		t.Helper()
+		r, err := h.RenderReader(name, "x")
+		if err != nil {
+			t.Fatalf("RenderReader %s: %v", name, err)
+		}
+		defer r.Close()
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	if got := read("old.html"); got != "page x" {
+		t.Fatalf("alias got %q", got)
+	}
+
+	// A template added on disk is found once development mode reloads
+	writeFiles(t, h.config.TemplateDir, map[string]string{"new.html": `new {{.}}`})
+	if got := read("new.html"); got != "new x" {
+		t.Fatalf("new template got %q", got)
+	}
+	if _, err := h.RenderReader("missing.html", nil); err == nil {
+		t.Fatal("missing template: want an error")
+	}
+}
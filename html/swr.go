@@ -0,0 +1,108 @@
+package html
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"time"
+)
+
+// swrEntry is the cached output of one RenderSWR key
+type swrEntry struct {
+	body     []byte
+	rendered time.Time
+	call     *swrCall // render in progress, nil when idle
+}
+
+// swrCall is a render in progress, shared by every caller waiting on the
+// same key
+type swrCall struct {
+	done chan struct{}
+	err  error
+}
+
+// RenderSWR renders through a stale-while-revalidate cache: a cached body
+// is written at once, and one older than ttl is re-rendered in the
+// background. Only a miss waits for the render.
+func (h *HTMLTemplate) RenderSWR(w io.Writer, name, key string, ttl time.Duration, data any) error {
+	h.swrMu.Lock()
+	if h.swr == nil {
+		h.swr = map[string]*swrEntry{}
+	}
+	e, ok := h.swr[key]
+	if !ok {
+		e = &swrEntry{}
+		h.swr[key] = e
+	}
+
+	if e.body != nil {
+		body := e.body
+		if e.call == nil && time.Since(e.rendered) > ttl {
+			call := &swrCall{done: make(chan struct{})}
+			e.call = call
+			go func() {
+				if err := h.renderSWR(e, call, name, data); err != nil && h.config.Development {
+					log.Printf("Background render of %s failed: %v", key, err)
+				}
+			}()
+		}
+		h.swrMu.Unlock()
+
+		_, err := w.Write(body)
+		return err
+	}
+
+	call := e.call
+	if call == nil {
+		call = &swrCall{done: make(chan struct{})}
+		e.call = call
+		h.swrMu.Unlock()
+		h.renderSWR(e, call, name, data)
+	} else {
+		h.swrMu.Unlock()
+		<-call.done
+	}
+	if call.err != nil {
+		return call.err
+	}
+
+	h.swrMu.Lock()
+	body := e.body
+	h.swrMu.Unlock()
+
+	_, err := w.Write(body)
+	return err
+}
+
+// renderSWR renders name into e and completes call. On error the entry
+// keeps its previous body.
+func (h *HTMLTemplate) renderSWR(e *swrEntry, call *swrCall, name string, data any) error {
+	var buf bytes.Buffer
+	call.err = h.Render(&buf, name, data)
+
+	h.swrMu.Lock()
+	if call.err == nil {
+		e.body = buf.Bytes()
+		e.rendered = time.Now()
+	}
+	e.call = nil
+	h.swrMu.Unlock()
+
+	close(call.done)
+	return call.err
+}
+
+// InvalidateSWR drops the cached bodies of the given RenderSWR keys, or of
+// every key when none are given
+func (h *HTMLTemplate) InvalidateSWR(keys ...string) {
+	h.swrMu.Lock()
+	defer h.swrMu.Unlock()
+
+	if len(keys) == 0 {
+		clear(h.swr)
+		return
+	}
+	for _, key := range keys {
+		delete(h.swr, key)
+	}
+}
@@ -0,0 +1,74 @@
+package html
+
+import (
+	"bytes"
+	"html/template"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRenderSWR(t *testing.T) {
+	var renders atomic.Int32
+	h := newTestEngine(t, map[string]string{"page.html": `{{count}}`},
+		WithFuncs(template.FuncMap{"count": func() int32 { return renders.Add(1) }}))
+
+	swr := func(ttl time.Duration) string {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := h.RenderSWR(&buf, "page.html", "k", ttl, nil); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	if got := swr(time.Hour); got != "1" {
+		t.Fatalf("miss got %q", got)
+	}
+	if got := swr(time.Hour); got != "1" || renders.Load() != 1 {
+		t.Fatalf("fresh hit got %q after %d renders", got, renders.Load())
+	}
+
+	// A stale body is served while it is re-rendered in the background
+	if got := swr(0); got != "1" {
+		t.Fatalf("stale hit got %q", got)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for swr(time.Hour) != "2" {
+		if time.Now().After(deadline) {
+			t.Fatal("background render never replaced the stale body")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	h.InvalidateSWR("k")
+	if got := swr(time.Hour); got != "3" {
+		t.Fatalf("after InvalidateSWR got %q", got)
+	}
+}
+
+func TestRenderSWRCoalescing(t *testing.T) {
+	var renders atomic.Int32
+	release := make(chan struct{})
+	h := newTestEngine(t, map[string]string{"page.html": `{{wait}}`},
+		WithFuncs(template.FuncMap{"wait": func() int32 {
+			<-release
+			return renders.Add(1)
+		}}))
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			var buf bytes.Buffer
+			if err := h.RenderSWR(&buf, "page.html", "k", time.Hour, nil); err != nil || buf.String() != "1" {
+				t.Errorf("got %q, %v", buf.String(), err)
+			}
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := renders.Load(); n != 1 {
+		t.Fatalf("%d renders, want the misses coalesced into one", n)
+	}
+}
@@ -0,0 +1,84 @@
+package html
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// fileTags returns the build tags a template declares in a leading comment
+// directive, written with the configured delimiters:
+//
+//	{{/* tags: [admin, experimental] */}}
+//
+// The brackets are optional. A file without the directive has no tags.
+func (c *Config) fileTags(src []byte) []string {
+	s := strings.TrimLeft(string(src), " \t\r\n")
+	open := c.Delimiters[0] + "/*"
+	if !strings.HasPrefix(s, open) {
+		open = c.Delimiters[0] + "- /*"
+		if !strings.HasPrefix(s, open) {
+			return nil
+		}
+	}
+	comment, _, ok := strings.Cut(s[len(open):], "*/")
+	if !ok {
+		return nil
+	}
+
+	for line := range strings.Lines(comment) {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "tags:")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), "[]")
+
+		var tags []string
+		for tag := range strings.SplitSeq(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		return tags
+	}
+	return nil
+}
+
+// tagged reports whether a template with tags is part of this build:
+// untagged templates always are, tagged ones when a tag is active
+func (c *Config) tagged(tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		if slices.Contains(c.ActiveTags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// includedFile reports whether the template file is part of this build.
+// Unreadable files are included so parsing reports them.
+func (c *Config) includedFile(file string) bool {
+	src, err := c.readFile(file)
+	if err != nil {
+		return true
+	}
+	return c.tagged(c.fileTags(src))
+}
+
+// globTagged returns the files matching pattern that are part of this
+// build
+func (c *Config) globTagged(pattern string) ([]string, error) {
+	files, err := c.glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("html/template: pattern matches no files: %#q", pattern)
+	}
+	return slices.DeleteFunc(files, func(file string) bool {
+		return !c.includedFile(file)
+	}), nil
+}
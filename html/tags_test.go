@@ -0,0 +1,46 @@
+package html
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestActiveTags(t *testing.T) {
+	files := map[string]string{
+		"page.html":  `page`,
+		"admin.html": "{{/* tags: [admin, internal] */}}admin",
+		"beta.html":  "\n{{- /*\n  owner: web\n  tags: beta\n*/ -}}\nbeta",
+	}
+
+	h := newTestEngine(t, files)
+	if !h.HasTemplate("page.html") || h.HasTemplate("admin.html") || h.HasTemplate("beta.html") {
+		t.Fatal("tagged templates parsed without an active tag")
+	}
+
+	h = newTestEngine(t, files, WithActiveTags([]string{"internal"}), WithActiveTags([]string{"beta"}))
+	if got := renderString(t, h, "admin.html", nil); got != "admin" {
+		t.Fatalf("admin got %q", got)
+	}
+	if got := renderString(t, h, "beta.html", nil); got != "beta" {
+		t.Fatalf("beta got %q", got)
+	}
+}
+
+func TestFileTags(t *testing.T) {
+	c := Sparkle("*.html").(*html).config
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"{{/* tags: [a, b] */}}", []string{"a", "b"}},
+		{"{{/* tags: a */}}", []string{"a"}},
+		{"text {{/* tags: a */}}", nil},
+		{"{{/* note */}}", nil},
+		{"{{/* tags: a", nil},
+	}
+	for _, tt := range tests {
+		if got := c.fileTags([]byte(tt.src)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
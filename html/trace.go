@@ -0,0 +1,35 @@
+package html
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Trace records one template execution of a traced render: the template
+// passed to RenderTraced at the root, and each partial or embed call below
+// it in call order. Plain {{template}} calls are not visible to the engine
+// and are counted in the time of the caller.
+type Trace struct {
+	Name     string
+	Kind     string // "template", "partial" or "embed"
+	Start    time.Time
+	Duration time.Duration
+	Children []*Trace
+
+	parent *Trace
+}
+
+// traceKey is the context key of the trace RenderTraced records into
+type traceKey struct{}
+
+// RenderTraced renders a template like Render and returns the tree of
+// template executions with their timings. Tracing adds overhead to every
+// partial and embed call and is meant for diagnosing slow or incorrect
+// renders during development.
+func (h *HTMLTemplate) RenderTraced(w io.Writer, name string, data any) (Trace, error) {
+	root := Trace{Name: h.resolve(name), Kind: "template", Start: time.Now()}
+	err := h.RenderContext(context.WithValue(context.Background(), traceKey{}, &root), w, name, data)
+	root.Duration = time.Since(root.Start)
+	return root, err
+}
@@ -0,0 +1,46 @@
+package html
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// traceString formats the tree as kind:name, children in parentheses
+func traceString(tr *Trace) string {
+	var kids []string
+	for _, c := range tr.Children {
+		kids = append(kids, traceString(c))
+	}
+	s := tr.Kind + ":" + tr.Name
+	if len(kids) > 0 {
+		s += "(" + strings.Join(kids, " ") + ")"
+	}
+	return s
+}
+
+func TestRenderTraced(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":         `{{partial "a.html" 1}}{{embed "b.html" 2}}{{template "c.html"}}`,
+		"a.html":            `a{{partial "c.html"}}`,
+		"b.html":            `b`,
+		"c.html":            `c`,
+		"layouts/base.html": `[{{template "content" .}}]`,
+	}, WithLayoutDir("layouts"), WithDefaultLayout("base"), WithAlias("home.html", "page.html"))
+
+	var buf bytes.Buffer
+	tr, err := h.RenderTraced(&buf, "home.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "ac[b]c" {
+		t.Fatalf("got %q", buf.String())
+	}
+	want := "template:page.html(partial:a.html(partial:c.html) embed:b.html)"
+	if got := traceString(&tr); got != want {
+		t.Fatalf("trace %s, want %s", got, want)
+	}
+	if tr.Duration <= 0 || tr.Children[0].Duration > tr.Duration {
+		t.Fatalf("durations %v, %v", tr.Duration, tr.Children[0].Duration)
+	}
+}
@@ -0,0 +1,236 @@
+package html
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"text/template/parse"
+)
+
+// walkTree calls fn for every node reachable from node, depth first. If fn
+// returns false the children of that node are skipped.
+func walkTree(node parse.Node, fn func(parse.Node) bool) {
+	if node == nil || !fn(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkTree(child, fn)
+		}
+	case *parse.ActionNode:
+		walkTree(n.Pipe, fn)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, decl := range n.Decl {
+			walkTree(decl, fn)
+		}
+		for _, cmd := range n.Cmds {
+			walkTree(cmd, fn)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkTree(arg, fn)
+		}
+	case *parse.ChainNode:
+		walkTree(n.Node, fn)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.TemplateNode:
+		walkTree(n.Pipe, fn)
+	}
+}
+
+func walkBranch(n *parse.BranchNode, fn func(parse.Node) bool) {
+	walkTree(n.Pipe, fn)
+	walkTree(n.List, fn)
+	if n.ElseList != nil {
+		walkTree(n.ElseList, fn)
+	}
+}
+
+// includeFuncs are the helpers whose first argument names a template
+var includeFuncs = []string{"partial", "embed", "joinTemplates", "includeIfExists", "lazy"}
+
+// optionalIncludes returns the template names t includes with
+// includeIfExists, which may legitimately be undefined
+func optionalIncludes(t *template.Template) map[string]bool {
+	names := map[string]bool{}
+	for _, tpl := range t.Templates() {
+		if tpl.Tree == nil {
+			continue
+		}
+		walkTree(tpl.Tree.Root, func(node parse.Node) bool {
+			n, ok := node.(*parse.CommandNode)
+			if !ok || len(n.Args) < 2 {
+				return true
+			}
+			if fn, ok := n.Args[0].(*parse.IdentifierNode); ok && fn.Ident == "includeIfExists" {
+				if name, ok := n.Args[1].(*parse.StringNode); ok {
+					names[name.Text] = true
+				}
+			}
+			return true
+		})
+	}
+	return names
+}
+
+// templateDeps maps each template in t to the templates it references
+// directly, through {{template}} or an include helper called with a
+// literal name
+func templateDeps(t *template.Template) map[string][]string {
+	deps := map[string][]string{}
+	for _, tpl := range t.Templates() {
+		if tpl.Tree == nil {
+			continue
+		}
+
+		var refs []string
+		walkTree(tpl.Tree.Root, func(node parse.Node) bool {
+			switch n := node.(type) {
+			case *parse.TemplateNode:
+				refs = append(refs, n.Name)
+			case *parse.CommandNode:
+				if len(n.Args) < 2 {
+					break
+				}
+				fn, ok := n.Args[0].(*parse.IdentifierNode)
+				if !ok || !slices.Contains(includeFuncs, fn.Ident) {
+					break
+				}
+				if name, ok := n.Args[1].(*parse.StringNode); ok {
+					refs = append(refs, name.Text)
+				}
+			}
+			return true
+		})
+
+		slices.Sort(refs)
+		deps[tpl.Name()] = slices.Compact(refs)
+	}
+	return deps
+}
+
+// dependsOn reports whether root is one of names or reaches one of them
+// through deps
+func dependsOn(deps map[string][]string, root string, names []string) bool {
+	for _, name := range reachable(deps, root) {
+		if slices.Contains(names, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// reachable returns roots and every template they reach through deps
+func reachable(deps map[string][]string, roots ...string) []string {
+	seen := map[string]bool{}
+	var names []string
+	queue := slices.Clone(roots)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		queue = append(queue, deps[name]...)
+	}
+	return names
+}
+
+// checkIncludeDepth fails when a template reaches another through more than
+// MaxIncludeDepth nested includes. Recursive templates are legitimate, so a
+// cycle ends the chain it is on instead of counting as infinitely deep;
+// cycles are logged in development mode.
+func (c *Config) checkIncludeDepth(deps map[string][]string) error {
+	if c.MaxIncludeDepth <= 0 {
+		return nil
+	}
+
+	cycles := map[string]bool{}
+	var chain func(name string, path []string, memo map[string][]string) []string
+	chain = func(name string, path []string, memo map[string][]string) []string {
+		if i := slices.Index(path, name); i >= 0 {
+			cycles[strings.Join(append(path[i:], name), " -> ")] = true
+			return nil
+		}
+		if longest, ok := memo[name]; ok {
+			return longest
+		}
+
+		var longest []string
+		for _, dep := range deps[name] {
+			if _, ok := deps[dep]; !ok {
+				continue
+			}
+			if c := chain(dep, slices.Concat(path, []string{name}), memo); len(c) > len(longest) {
+				longest = c
+			}
+		}
+		longest = slices.Concat([]string{name}, longest)
+		memo[name] = longest
+		return longest
+	}
+
+	// Measure from every template, as a chain may only be reachable
+	// through a cycle. The memo is per start, since which edges close a
+	// cycle depends on where the walk began.
+	names := slices.Sorted(maps.Keys(deps))
+	deep := map[string][]string{}
+	for _, name := range names {
+		if longest := chain(name, nil, map[string][]string{}); len(longest)-1 > c.MaxIncludeDepth {
+			deep[name] = longest
+		}
+	}
+
+	// Report each deep chain once, from its start rather than from every
+	// template further along it
+	var errs []error
+	for _, name := range names {
+		longest, ok := deep[name]
+		if !ok || onLongerChain(deep, name) {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("template %s includes %d levels deep, more than %d: %s",
+			name, len(longest)-1, c.MaxIncludeDepth, strings.Join(longest, " -> ")))
+	}
+
+	if c.Development {
+		for _, cycle := range slices.Sorted(maps.Keys(cycles)) {
+			log.Printf("Template recursion: %s", cycle)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// onLongerChain reports whether name is further along the chain of another
+// template in chains, one that is longer or, on a cycle where each chain
+// holds the other, equally long and sorts first
+func onLongerChain(chains map[string][]string, name string) bool {
+	for other, chain := range chains {
+		if other == name || !slices.Contains(chain[1:], name) {
+			continue
+		}
+		if n := len(chains[name]); len(chain) > n || len(chain) == n && other < name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package html
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaxIncludeDepth(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.html": `{{template "b.html" .}}`,
+		"b.html": `{{partial "c.html" .}}`,
+		"c.html": `{{template "d.html" .}}`,
+		"d.html": `d`,
+	})
+
+	_, err := Sparkle("*.html", WithTemplateDir(dir), WithMaxIncludeDepth(2)).CreateEngine()
+	if err == nil || !strings.Contains(err.Error(), "template a.html includes 3 levels deep, more than 2: a.html -> b.html -> c.html -> d.html") {
+		t.Fatalf("got %v", err)
+	}
+	if _, err := Sparkle("*.html", WithTemplateDir(dir), WithMaxIncludeDepth(3)).CreateEngine(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMaxIncludeDepthThroughCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"x.html":  `{{partial "y.html" .}}`,
+		"y.html":  `{{partial "x.html" .}}{{template "z1.html" .}}`,
+		"z1.html": `{{template "z2.html" .}}`,
+		"z2.html": `{{template "z3.html" .}}`,
+		"z3.html": `z`,
+	})
+
+	_, err := Sparkle("*.html", WithTemplateDir(dir), WithMaxIncludeDepth(2)).CreateEngine()
+	want := "template x.html includes 4 levels deep, more than 2: x.html -> y.html -> z1.html -> z2.html -> z3.html"
+	if err == nil || err.Error() != want {
+		t.Fatalf("got %v, want %q alone", err, want)
+	}
+}
+
+func TestMaxIncludeDepthRecursion(t *testing.T) {
+	logs := captureLogs(t)
+	h := newTestEngine(t, map[string]string{
+		"page.html": `{{partial "tree.html" .}}`,
+		"tree.html": `{{range .}}<li>{{.Name}}{{partial "tree.html" .Children}}</li>{{end}}`,
+	}, WithMaxIncludeDepth(2), WithDevelopment(true))
+
+	if !h.HasTemplate("tree.html") {
+		t.Fatal("recursive template rejected")
+	}
+	if !strings.Contains(logs.String(), "Template recursion: tree.html -> tree.html") {
+		t.Fatalf("no recursion logged in %q", logs.String())
+	}
+}
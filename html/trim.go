@@ -0,0 +1,64 @@
+package html
+
+import (
+	"bytes"
+	"html/template"
+	"regexp"
+	"text/template/parse"
+)
+
+// preTag matches the opening and closing tags of elements whose whitespace
+// is significant
+var preTag = regexp.MustCompile(`(?i)<(/?)(pre|textarea)[\s>]`)
+
+// autoTrim trims the whitespace around every action in t, as if each were
+// written with {{- -}} markers, except inside <pre> and <textarea>
+func autoTrim(t *template.Template) {
+	for _, tpl := range t.Templates() {
+		if tpl.Tree == nil || tpl.Tree.Root == nil {
+			continue
+		}
+		depth := 0
+		trimList(tpl.Tree.Root, &depth, false)
+	}
+}
+
+// trimList trims the text nodes of list next to actions. nested is true for
+// the body of an if, range or with, whose ends touch the enclosing actions.
+// depth counts the open <pre> and <textarea> elements in document order.
+func trimList(list *parse.ListNode, depth *int, nested bool) {
+	if list == nil {
+		return
+	}
+
+	for i, node := range list.Nodes {
+		var branch *parse.BranchNode
+		switch n := node.(type) {
+		case *parse.IfNode:
+			branch = &n.BranchNode
+		case *parse.RangeNode:
+			branch = &n.BranchNode
+		case *parse.WithNode:
+			branch = &n.BranchNode
+		case *parse.TextNode:
+			if *depth == 0 && (i > 0 || nested) {
+				n.Text = bytes.TrimLeft(n.Text, " \t\r\n")
+			}
+			for _, m := range preTag.FindAllSubmatch(n.Text, -1) {
+				if len(m[1]) == 0 {
+					*depth++
+				} else if *depth > 0 {
+					*depth--
+				}
+			}
+			if *depth == 0 && (i < len(list.Nodes)-1 || nested) {
+				n.Text = bytes.TrimRight(n.Text, " \t\r\n")
+			}
+		}
+
+		if branch != nil {
+			trimList(branch.List, depth, true)
+			trimList(branch.ElseList, depth, true)
+		}
+	}
+}
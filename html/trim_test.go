@@ -0,0 +1,21 @@
+package html
+
+import "testing"
+
+func TestAutoTrim(t *testing.T) {
+	auto := newTestEngine(t, map[string]string{
+		"page.html": "<ul>\n  {{range .}}\n    <li>{{.}}</li>\n  {{end}}\n</ul>\n<pre>\n  {{index . 0}}\n</pre>\n<p>\n  {{len .}}\n</p>\n",
+	}, WithAutoTrim(true))
+	manual := newTestEngine(t, map[string]string{
+		"page.html": "<ul>\n  {{- range . -}}\n    <li>{{.}}</li>\n  {{- end -}}\n</ul>\n<pre>\n  {{index . 0}}\n</pre>\n<p>\n  {{- len . -}}\n</p>\n",
+	})
+
+	data := []string{"a", "b"}
+	got, want := renderString(t, auto, "page.html", data), renderString(t, manual, "page.html", data)
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if want := "<ul><li>a</li><li>b</li></ul>\n<pre>\n  a\n</pre>\n<p>2</p>\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+package html
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// WarmPartials escapes every template page reaches on a fresh render set
+// ahead of its first request, without executing anything
+func (h *HTMLTemplate) WarmPartials(page string) error {
+	page = h.resolve(page)
+	if err := h.validateTemplate(page); err != nil {
+		return err
+	}
+
+	h.mu.RLock()
+	base, pool, deps := h.base, h.sets, h.deps
+	h.mu.RUnlock()
+
+	t, err := base.Clone()
+	if err != nil {
+		return err
+	}
+	if err := h.warmSet(t, pool, reachable(deps, page)); err != nil {
+		return fmt.Errorf("warm %s: %w", page, err)
+	}
+
+	layout := h.layoutFor(page)
+	if layout == "" || !h.config.EnableCache {
+		return nil
+	}
+	layout = h.resolve(layout)
+	t, gen, err := h.wireLayout(layout, page)
+	if err != nil {
+		return fmt.Errorf("warm %s: layout %s: %w", page, layout, err)
+	}
+	if err := h.warmSet(t, &h.layoutEntry(layout, page, gen).sets, reachable(deps, layout, page)); err != nil {
+		return fmt.Errorf("warm %s in layout %s: %w", page, layout, err)
+	}
+	return nil
+}
+
+// warmSet escapes names on the fresh set t and adds it to pool. Each name
+// is escaped by executing a template that calls it in a branch never taken,
+// since html/template escapes a template only when it runs. Names missing
+// from the set, left by includeIfExists, are skipped.
+func (h *HTMLTemplate) warmSet(t *template.Template, pool *sync.Pool, names []string) error {
+	left, right := h.config.Delimiters[0], h.config.Delimiters[1]
+	var warmers []*template.Template
+	var warmed []string
+	for _, name := range names {
+		if t.Lookup(name) == nil {
+			continue
+		}
+		src := left + "if false" + right + left + "template " + strconv.Quote(name) + " ." + right + left + "end" + right
+		warmer, err := t.New("\x00warm " + name).Parse(src)
+		if err != nil {
+			return fmt.Errorf("dependency %s: %w", name, err)
+		}
+		warmers = append(warmers, warmer)
+		warmed = append(warmed, name)
+	}
+
+	rs := &renderState{h: h, t: t}
+	t.Funcs(rs.funcs())
+	for i, warmer := range warmers {
+		if err := warmer.Execute(io.Discard, nil); err != nil {
+			return fmt.Errorf("dependency %s: %w", warmed[i], err)
+		}
+	}
+
+	pool.Put(&renderSet{t: t, rs: rs, pool: pool})
+	return nil
+}
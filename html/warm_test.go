@@ -0,0 +1,65 @@
+package html
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// warmedSet warms page and returns a render set it pooled. The pool may
+// drop a set under the race detector, so warming is retried.
+func warmedSet(t *testing.T, h *HTMLTemplate, pool func() *sync.Pool, page string) *renderSet {
+	t.Helper()
+	for range 20 {
+		if err := h.WarmPartials(page); err != nil {
+			t.Fatal(err)
+		}
+		if s, ok := pool().Get().(*renderSet); ok {
+			return s
+		}
+	}
+	t.Fatal("no render set pooled")
+	return nil
+}
+
+func TestWarmPartials(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":         `{{template "card.html" .}}{{partial "list.html" .}}`,
+		"card.html":         `card`,
+		"list.html":         `{{includeIfExists "gone.html"}}{{template "item.html"}}`,
+		"item.html":         `item`,
+		"other.html":        `other`,
+		"layouts/base.html": `<main>{{template "content" .}}</main>`,
+	}, WithLayoutDir("layouts"), WithDefaultLayout("base"))
+
+	s := warmedSet(t, h, func() *sync.Pool { return h.sets }, "page.html")
+	for _, name := range []string{"page.html", "card.html", "list.html", "item.html"} {
+		if s.t.Lookup("\x00warm "+name) == nil {
+			t.Errorf("%s not warmed", name)
+		}
+	}
+	if s.t.Lookup("\x00warm other.html") != nil {
+		t.Error("unrelated other.html warmed")
+	}
+
+	s = warmedSet(t, h, func() *sync.Pool { return &h.layoutEntry("base", "page.html", h.cfgGen).sets }, "page.html")
+	for _, name := range []string{"base", "page.html", "item.html"} {
+		if s.t.Lookup("\x00warm "+name) == nil {
+			t.Errorf("%s not warmed in its layout", name)
+		}
+	}
+}
+
+func TestWarmPartialsError(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":   `{{partial "broken.html" .}}`,
+		"broken.html": `<a href="{{.}}`,
+	})
+	err := h.WarmPartials("page.html")
+	if err == nil || !strings.HasPrefix(err.Error(), "warm page.html: dependency broken.html: ") {
+		t.Fatalf("got %v", err)
+	}
+	if err := h.WarmPartials("missing.html"); err == nil {
+		t.Fatal("missing page: want an error")
+	}
+}
@@ -0,0 +1,62 @@
+package html
+
+import (
+	"path"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// reloadDue reports whether a development render should reparse the
+// templates: not within WatchDebounce of the last load, and, when
+// WatchIgnore is set, only once a template file outside it has changed
+func (h *HTMLTemplate) reloadDue() bool {
+	h.mu.RLock()
+	last := h.lastLoad
+	h.mu.RUnlock()
+
+	if d := h.config.WatchDebounce; d > 0 && time.Since(last) < d {
+		return false
+	}
+	if len(h.config.WatchIgnore) > 0 {
+		return h.config.changedSince(h.pattern, last)
+	}
+	return true
+}
+
+// changedSince reports whether a template or layout file not matched by
+// WatchIgnore was modified after t
+func (c *Config) changedSince(pattern string, t time.Time) bool {
+	files, _ := c.glob(filepath.Join(c.TemplateDir, pattern))
+	if c.LayoutDir != "" {
+		layouts, _ := c.glob(filepath.Join(c.TemplateDir, c.LayoutDir, "*"))
+		files = append(files, layouts...)
+	}
+
+	for _, file := range files {
+		if c.watchIgnored(file) {
+			continue
+		}
+		if info, err := c.stat(file); err == nil && info.ModTime().After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// watchIgnored reports whether file, or its path below TemplateDir, matches
+// one of the WatchIgnore globs
+func (c *Config) watchIgnored(file string) bool {
+	rel, err := filepath.Rel(c.TemplateDir, file)
+	if err != nil {
+		rel = file
+	}
+	rel = filepath.ToSlash(rel)
+	return slices.ContainsFunc(c.WatchIgnore, func(pattern string) bool {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	})
+}
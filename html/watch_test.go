@@ -0,0 +1,147 @@
+package html
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// gatedFS counts the opens of one file and, while gate is set, holds them
+// until it is closed
+type gatedFS struct {
+	fsys  fs.FS
+	name  string
+	opens atomic.Int32
+	gate  atomic.Pointer[chan struct{}]
+}
+
+func (g *gatedFS) Open(name string) (fs.File, error) {
+	if path.Base(name) == g.name {
+		g.opens.Add(1)
+		if gate := g.gate.Load(); gate != nil {
+			<-*gate
+		}
+	}
+	return g.fsys.Open(name)
+}
+
+func TestReloadCoalescing(t *testing.T) {
+	captureLogs(t)
+	gfs := &gatedFS{fsys: fstest.MapFS{"templates/page.html": {Data: []byte(`page`)}}, name: "page.html"}
+	engine, err := Sparkle("*.html", WithTemplateFS(gfs), WithDevelopment(true)).CreateEngine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := engine.(*HTMLTemplate)
+
+	before := gfs.opens.Load()
+	if err := h.reloadIfNeeded(); err != nil {
+		t.Fatal(err)
+	}
+	perReload := gfs.opens.Load() - before
+
+	// Hold the first reload in its parse while the others arrive
+	gate := make(chan struct{})
+	gfs.gate.Store(&gate)
+	before = gfs.opens.Load()
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			if err := h.reloadIfNeeded(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	time.Sleep(100 * time.Millisecond)
+	gfs.gate.Store(nil)
+	close(gate)
+	wg.Wait()
+
+	if got := gfs.opens.Load() - before; got != perReload {
+		t.Fatalf("8 concurrent reloads opened page.html %d times, want %d for one parse", got, perReload)
+	}
+	if got := renderString(t, h, "page.html", nil); got != "page" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+// lastLoad returns when the templates were last parsed
+func lastLoad(h *HTMLTemplate) time.Time {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.lastLoad
+}
+
+func TestWatchDebounce(t *testing.T) {
+	h := newTestEngine(t, map[string]string{"page.html": `v1`}, WithDevelopment(true), WithWatchDebounce(time.Hour))
+	dir := h.config.TemplateDir
+	elapse := func() {
+		h.mu.Lock()
+		h.lastLoad = h.lastLoad.Add(-2 * time.Hour)
+		h.mu.Unlock()
+	}
+
+	// A burst of changes within the window doesn't reparse
+	loaded := lastLoad(h)
+	for _, v := range []string{"v2", "v3", "v4"} {
+		writeFiles(t, dir, map[string]string{"page.html": v})
+		if got := renderString(t, h, "page.html", nil); got != "v1" {
+			t.Fatalf("reparsed within the window: %q", got)
+		}
+	}
+	if !lastLoad(h).Equal(loaded) {
+		t.Fatal("reparsed within the window")
+	}
+
+	// Once it has passed, one render reparses and starts a new window
+	elapse()
+	for range 3 {
+		if got := renderString(t, h, "page.html", nil); got != "v4" {
+			t.Fatalf("got %q, want v4", got)
+		}
+	}
+	reloaded := lastLoad(h)
+	if !reloaded.After(loaded) {
+		t.Fatal("no reparse after the window")
+	}
+
+	elapse()
+	writeFiles(t, dir, map[string]string{"page.html": "v5"})
+	if got := renderString(t, h, "page.html", nil); got != "v5" {
+		t.Fatalf("got %q, want v5", got)
+	}
+}
+
+func TestWatchIgnore(t *testing.T) {
+	h := newTestEngine(t, map[string]string{
+		"page.html":       `page v1`,
+		"draft-post.html": `draft v1`,
+	}, WithDevelopment(true), WithWatchIgnore("draft-*"))
+	dir := h.config.TemplateDir
+	touch := func(name, src string) {
+		writeFiles(t, dir, map[string]string{name: src})
+		future := time.Now().Add(time.Minute)
+		if err := os.Chtimes(filepath.Join(dir, name), future, future); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	touch("draft-post.html", "draft v2")
+	if got := renderString(t, h, "draft-post.html", nil); got != "draft v1" {
+		t.Fatalf("ignored change reparsed: %q", got)
+	}
+
+	touch("page.html", "page v2")
+	if got := renderString(t, h, "draft-post.html", nil); got != "draft v2" {
+		t.Fatalf("got %q, want draft v2", got)
+	}
+	if got := renderString(t, h, "page.html", nil); got != "page v2" {
+		t.Fatalf("got %q, want page v2", got)
+	}
+}